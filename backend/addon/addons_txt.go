@@ -74,6 +74,19 @@ func AddToAddonsTxt(addonName string) error {
 	return nil
 }
 
+// restoreAddonsTxt writes names back to addons.txt, undoing the changes made since they were read.
+func restoreAddonsTxt(names []string) error {
+	installedAddonNamesMu.Lock()
+	defer installedAddonNamesMu.Unlock()
+
+	if err := file.WriteLines(filepath.Join(config.GetAddonDir(), "addons.txt"), names); err != nil {
+		logger.Error("Error restoring addons.txt:", err)
+		return err
+	}
+	installedAddonNames = slices.Clone(names)
+	return nil
+}
+
 func CreateAddonsTxt() {
 	err := file.WriteLines(filepath.Join(config.GetAddonDir(), "addons.txt"), []string{})
 	if err != nil {
@@ -111,3 +124,32 @@ func RemoveFromAddonsTxt(addonName string) error {
 
 	return nil
 }
+
+// ReplaceInAddonsTxt puts newName in place of oldName in addons.txt, keeping its position in the load order.
+func ReplaceInAddonsTxt(oldName string, newName string) error {
+	installedAddonNamesMu.Lock()
+	defer installedAddonNamesMu.Unlock()
+
+	idx := slices.Index(installedAddonNames, oldName)
+	if idx < 0 {
+		return nil
+	}
+
+	names := slices.Clone(installedAddonNames)
+	if existing := slices.Index(names, newName); existing >= 0 {
+		names = slices.Delete(names, existing, existing+1)
+		if existing < idx {
+			idx--
+		}
+	}
+	names[idx] = newName
+
+	err := file.WriteLines(filepath.Join(config.GetAddonDir(), "addons.txt"), names)
+	if err != nil {
+		logger.Error("Error replacing addon in addons.txt:", err)
+		return err
+	}
+	installedAddonNames = names
+
+	return nil
+}
@@ -0,0 +1,313 @@
+package addon
+
+import (
+	"ClassicAddonManager/backend/api"
+	"ClassicAddonManager/backend/config"
+	"ClassicAddonManager/backend/file"
+	"ClassicAddonManager/backend/logger"
+	"ClassicAddonManager/backend/shared"
+	"ClassicAddonManager/backend/util"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Confidence levels of an adoption candidate.
+const (
+	// AdoptConfidenceHigh means the files of the folder are identical to those of a release.
+	AdoptConfidenceHigh = "high"
+	// AdoptConfidenceMedium means the folder is named after the registry addon and its main.lua declares
+	// the version of one of its releases.
+	AdoptConfidenceMedium = "medium"
+	// AdoptConfidenceLow means only the name matches, the installed version is unknown.
+	AdoptConfidenceLow = "low"
+)
+
+// adoptReleasesToCompare bounds how many of the newest releases are downloaded to compare files against.
+const adoptReleasesToCompare = 5
+
+// AdoptionCandidate proposes the registry addon an unmanaged addon folder is likely a copy of.
+type AdoptionCandidate struct {
+	// Name is the folder of the unmanaged addon
+	Name     string               `json:"name"`
+	Manifest shared.AddonManifest `json:"manifest"`
+	// Version is the release the folder matches, empty when it is unknown
+	Version    string `json:"version"`
+	Confidence string `json:"confidence"`
+	Reason     string `json:"reason"`
+}
+
+// FindAdoptionCandidates matches every installed addon that isn't managed against the registry. With
+// compareFiles set, archives of recent releases are downloaded to look for one identical to the folder.
+func FindAdoptionCandidates(ctx context.Context, compareFiles bool) ([]AdoptionCandidate, error) {
+	manifests := GetAddonManifest(ctx)
+	if len(manifests) == 0 {
+		return nil, errors.New("failed to fetch addon manifests")
+	}
+
+	candidates := []AdoptionCandidate{}
+	for _, name := range GetAllAddonNames() {
+		if local := FindLocalAddonByName(name); local != nil && local.IsManaged {
+			continue
+		}
+
+		candidate, ok := matchUnmanagedAddon(ctx, name, manifests, compareFiles)
+		if ok {
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	return candidates, nil
+}
+
+// FindAdoptionCandidate matches one unmanaged addon against the registry, see FindAdoptionCandidates.
+func FindAdoptionCandidate(ctx context.Context, name string, compareFiles bool) (AdoptionCandidate, bool) {
+	if local := FindLocalAddonByName(name); (local != nil && local.IsManaged) || !IsInstalled(name) {
+		return AdoptionCandidate{}, false
+	}
+
+	return matchUnmanagedAddon(ctx, name, GetAddonManifest(ctx), compareFiles)
+}
+
+// findManifestForFolder picks the registry addon a folder belongs to: the one named like it, ignoring case,
+// or the one whose alias is the name declared in its main.lua.
+func findManifestForFolder(name string, manifests []shared.AddonManifest) (shared.AddonManifest, string, bool) {
+	for _, m := range manifests {
+		if m.Name == name {
+			return m, "folder name matches", true
+		}
+	}
+	for _, m := range manifests {
+		if strings.EqualFold(m.Name, name) {
+			return m, "folder name matches ignoring case", true
+		}
+	}
+
+	if info, ok := readLuaAddonInfo(name); ok {
+		for _, m := range manifests {
+			if m.Alias != "" && strings.EqualFold(m.Alias, info.Name) {
+				return m, "name in main.lua matches " + m.Alias, true
+			}
+		}
+	}
+
+	return shared.AddonManifest{}, "", false
+}
+
+func matchUnmanagedAddon(ctx context.Context, name string, manifests []shared.AddonManifest, compareFiles bool) (AdoptionCandidate, bool) {
+	manifest, reason, ok := findManifestForFolder(name, manifests)
+	if !ok {
+		return AdoptionCandidate{}, false
+	}
+
+	candidate := AdoptionCandidate{
+		Name:       name,
+		Manifest:   manifest,
+		Confidence: AdoptConfidenceLow,
+		Reason:     reason,
+	}
+
+	releases, err := api.GetAddonReleases(ctx, manifest.Name, 1, 100)
+	if err != nil {
+		logger.Warn(name + " - Could not list releases of " + manifest.Name + ": " + err.Error())
+		return candidate, true
+	}
+
+	if info, ok := readLuaAddonInfo(name); ok && info.Version != "" {
+		for _, release := range releases.Releases {
+			if sameVersion(info.Version, release.TagName) {
+				candidate.Version = release.TagName
+				if manifest.Name == name {
+					candidate.Confidence = AdoptConfidenceMedium
+				}
+				candidate.Reason += ", main.lua declares version " + info.Version
+				break
+			}
+		}
+	}
+
+	if !compareFiles {
+		return candidate, true
+	}
+
+	local, err := hashInstalledFiles(name)
+	if err != nil {
+		logger.Warn(name + " - Could not hash installed files: " + err.Error())
+		return candidate, true
+	}
+
+	for i, release := range releases.Releases {
+		if i >= adoptReleasesToCompare {
+			break
+		}
+
+		matches, err := releaseMatchesFiles(ctx, manifest, release, name, local)
+		if err != nil {
+			logger.Warn(name + " - Could not compare with " + manifest.Name + " " + release.TagName + ": " + err.Error())
+			continue
+		}
+		if matches {
+			candidate.Version = release.TagName
+			candidate.Confidence = AdoptConfidenceHigh
+			candidate.Reason = "files are identical to " + manifest.Name + " " + release.TagName
+			pruneCachedArchives(manifest.Name, release)
+			return candidate, true
+		}
+	}
+
+	pruneCachedArchives(manifest.Name, api.Release{})
+	return candidate, true
+}
+
+// releaseMatchesFiles downloads the archive of release and reports whether its files, apart from the paths
+// the installed addon preserves, are exactly those hashed in local.
+func releaseMatchesFiles(ctx context.Context, manifest shared.AddonManifest, release api.Release, name string, local map[string]string) (bool, error) {
+	if _, err := fetchReleaseArchive(ctx, manifest, release); err != nil {
+		return false, err
+	}
+
+	rules := installedPreserveRules(name)
+	released, err := util.HashZipRelease(archivePath(manifest.Name, archiveKey(release)), func(rel string) bool {
+		return matchesPreserveRule(rel, rules)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return maps.Equal(local, released), nil
+}
+
+// sameVersion compares a version declared in main.lua with a release tag, so "1.2" matches "v1.2.0".
+func sameVersion(declared string, tag string) bool {
+	if declared == tag {
+		return true
+	}
+
+	a, err := util.ParseVersion(declared)
+	if err != nil {
+		return false
+	}
+	b, err := util.ParseVersion(tag)
+	if err != nil {
+		return false
+	}
+	return a.Compare(b) == 0
+}
+
+// AdoptAddon makes an unmanaged addon folder a managed install of the registry addon manifestName at
+// version, an empty version when it is unknown so the next update check offers the latest release. A folder
+// named differently from the registry addon is renamed, keeping its place in addons.txt. File hashes are only
+// recorded when the folder is identical to the release.
+func AdoptAddon(ctx context.Context, name string, manifestName string, version string) error {
+	if !IsInstalled(name) {
+		return fmt.Errorf("%s is not installed", name)
+	}
+	if local := FindLocalAddonByName(name); local != nil && local.IsManaged {
+		return fmt.Errorf("%s is already managed", name)
+	}
+
+	var manifest shared.AddonManifest
+	found := false
+	for _, m := range GetAddonManifest(ctx) {
+		if m.Name == manifestName {
+			manifest, found = m, true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("addon %s not found in repository manifests", manifestName)
+	}
+
+	var release api.Release
+	if version != "" {
+		var err error
+		release, err = api.GetAddonRelease(ctx, manifest.Name, version, api.Channel{})
+		if err != nil {
+			return err
+		}
+	}
+
+	addonFilesMu.Lock()
+	defer addonFilesMu.Unlock()
+
+	if name != manifest.Name {
+		if err := renameAddonFolder(name, manifest.Name); err != nil {
+			return err
+		}
+	}
+
+	files, archiveHash := adoptedFileHashes(manifest, release)
+
+	logger.Info("Adopting " + name + " as " + manifest.Name + " " + release.TagName)
+	previous := FindLocalAddonByName(manifest.Name)
+	if err := AddManagedAddon(manifest, release, archiveHash, files, InstallReasonExplicit); err != nil {
+		if previous != nil {
+			LocalAddons[manifest.Name] = *previous
+		} else {
+			delete(LocalAddons, manifest.Name)
+		}
+		if name != manifest.Name {
+			if renameErr := renameAddonFolder(manifest.Name, name); renameErr != nil {
+				logger.Error(name+" - Error restoring folder name after failed adoption:", renameErr)
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+// renameAddonFolder moves an addon folder and its addons.txt entry to a new name.
+func renameAddonFolder(oldName string, newName string) error {
+	newDir := filepath.Join(config.GetAddonDir(), newName)
+	// A rename that only changes case finds the folder itself on case-insensitive file systems
+	if !strings.EqualFold(oldName, newName) && file.FileExists(newDir) {
+		return fmt.Errorf("cannot rename %s to %s, the folder already exists", oldName, newName)
+	}
+
+	if err := os.Rename(filepath.Join(config.GetAddonDir(), oldName), newDir); err != nil {
+		return fmt.Errorf("could not rename %s to %s: %w", oldName, newName, err)
+	}
+
+	if err := renameInAddonLists(oldName, newName); err != nil {
+		_ = os.Rename(newDir, filepath.Join(config.GetAddonDir(), oldName))
+		return err
+	}
+
+	return nil
+}
+
+// adoptedFileHashes returns the file hashes and archive hash to record for an adopted addon, which are only
+// known when the cached archive of release is identical to the folder.
+func adoptedFileHashes(manifest shared.AddonManifest, release api.Release) (map[string]string, string) {
+	if release.TagName == "" {
+		return nil, ""
+	}
+
+	zipPath := archivePath(manifest.Name, archiveKey(release))
+	if !file.FileExists(zipPath) {
+		return nil, ""
+	}
+
+	local, err := hashInstalledFiles(manifest.Name)
+	if err != nil {
+		return nil, ""
+	}
+
+	rules := installedPreserveRules(manifest.Name)
+	released, err := util.HashZipRelease(zipPath, func(rel string) bool {
+		return matchesPreserveRule(rel, rules)
+	})
+	if err != nil || !maps.Equal(local, released) {
+		return nil, ""
+	}
+
+	archiveHash, err := util.HashFile(zipPath)
+	if err != nil {
+		return nil, ""
+	}
+	return local, archiveHash
+}
@@ -0,0 +1,135 @@
+package addon
+
+import (
+	"ClassicAddonManager/backend/api"
+	"ClassicAddonManager/backend/config"
+	"ClassicAddonManager/backend/file"
+	"ClassicAddonManager/backend/logger"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// cachedArchivesPerAddon is how many release archives are kept per addon for offline reinstalls.
+const cachedArchivesPerAddon = 3
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// archiveKey identifies the archive of a release in the cache. Branch heads share their tag name, so they
+// are told apart by commit.
+func archiveKey(release api.Release) string {
+	if release.Branch == "" {
+		return release.TagName
+	}
+	sha := release.Tag.Sha
+	if len(sha) > 12 {
+		sha = sha[:12]
+	}
+	return release.Branch + "-" + sha
+}
+
+// archiveRelPath returns the path of a release archive relative to the cache dir.
+func archiveRelPath(name string, key string) string {
+	return filepath.Join("archives", name, unsafeFileChars.ReplaceAllString(key, "_")+".zip")
+}
+
+func archivePath(name string, key string) string {
+	return filepath.Join(config.GetCacheDir(), archiveRelPath(name, key))
+}
+
+func releaseMetaPath(name string, key string) string {
+	return strings.TrimSuffix(archivePath(name, key), ".zip") + ".json"
+}
+
+func archiveDir(name string) string {
+	return filepath.Join(config.GetCacheDir(), "archives", name)
+}
+
+// saveCachedRelease stores the release metadata next to its archive so it can be reinstalled offline.
+func saveCachedRelease(name string, release api.Release) {
+	data, err := json.Marshal(release)
+	if err != nil {
+		logger.Error("Error marshalling cached release:", err)
+		return
+	}
+
+	if err := file.WriteJSON(releaseMetaPath(name, archiveKey(release)), data); err != nil {
+		logger.Error("Error writing cached release:", err)
+	}
+}
+
+// getCachedReleases returns every cached release of an addon that still has its archive, newest first.
+func getCachedReleases(name string) []api.Release {
+	entries, err := os.ReadDir(archiveDir(name))
+	if err != nil {
+		return nil
+	}
+
+	var releases []api.Release
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(archiveDir(name), e.Name()))
+		if err != nil {
+			continue
+		}
+
+		var release api.Release
+		if err := json.Unmarshal(data, &release); err != nil {
+			continue
+		}
+
+		if !file.FileExists(archivePath(name, archiveKey(release))) {
+			continue
+		}
+
+		releases = append(releases, release)
+	}
+
+	sort.SliceStable(releases, func(i, j int) bool {
+		return releases[i].PublishedAt.After(releases[j].PublishedAt)
+	})
+
+	return releases
+}
+
+// findCachedRelease looks up a cached release by tag, or the newest one on channel for "" and "latest".
+func findCachedRelease(name string, version string, channel api.Channel) (api.Release, bool) {
+	releases := getCachedReleases(name)
+	for _, release := range releases {
+		if version == "" || version == "latest" {
+			if release.Branch == channel.Branch {
+				return release, true
+			}
+		} else if release.TagName == version {
+			return release, true
+		}
+	}
+	return api.Release{}, false
+}
+
+// pruneCachedArchives removes all but the newest cachedArchivesPerAddon archives of an addon,
+// never removing the archive of keep.
+func pruneCachedArchives(name string, keep api.Release) {
+	releases := getCachedReleases(name)
+	if len(releases) <= cachedArchivesPerAddon {
+		return
+	}
+
+	for _, release := range releases[cachedArchivesPerAddon:] {
+		if archiveKey(release) == archiveKey(keep) {
+			continue
+		}
+		if err := os.Remove(archivePath(name, archiveKey(release))); err != nil && !os.IsNotExist(err) {
+			logger.Error("Error removing cached archive:", err)
+		}
+		if err := os.Remove(releaseMetaPath(name, archiveKey(release))); err != nil && !os.IsNotExist(err) {
+			logger.Error("Error removing cached release:", err)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package addon
+
+import (
+	"ClassicAddonManager/backend/config"
+	"ClassicAddonManager/backend/file"
+	"ClassicAddonManager/backend/logger"
+	"ClassicAddonManager/backend/util"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultBackupRetention is how many backups are kept per addon unless general.backupretention is set.
+const DefaultBackupRetention = 5
+
+const backupIDFormat = "20060102-150405.000"
+
+type Backup struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Version   string    `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+	Size      int64     `json:"size"`
+}
+
+// backupMeta is stored next to each backup archive. Addon is the managed_addons.json entry at the time
+// of the backup, nil for an unmanaged addon.
+type backupMeta struct {
+	Backup
+	Addon *Addon `json:"addon,omitempty"`
+}
+
+// GetBackupRetention returns how many backups are kept per addon. Zero disables backups.
+func GetBackupRetention() int {
+	if !config.IsSet("general.backupretention") {
+		return DefaultBackupRetention
+	}
+	return max(config.GetInt("general.backupretention"), 0)
+}
+
+// isSafeBackupPathPart reports whether an addon name or backup id from the frontend can be joined into a
+// path under the backup dir without leaving it.
+func isSafeBackupPathPart(s string) bool {
+	return s != "" && s != "." && s != ".." && !unsafeFileChars.MatchString(s)
+}
+
+func backupDir(name string) string {
+	return filepath.Join(config.GetDataDir(), "backups", name)
+}
+
+func backupArchivePath(name string, id string) string {
+	return filepath.Join(backupDir(name), id+".zip")
+}
+
+func backupMetaPath(name string, id string) string {
+	return filepath.Join(backupDir(name), id+".json")
+}
+
+// createAddonBackup archives the current folder of an addon, including .data, together with its
+// managed_addons.json entry. Nothing is done when backups are disabled or the addon isn't installed.
+func createAddonBackup(name string) error {
+	retention := GetBackupRetention()
+	if retention == 0 {
+		return nil
+	}
+
+	addonPath := filepath.Join(config.GetAddonDir(), name)
+	if !file.FileExists(addonPath) {
+		return nil
+	}
+
+	if err := os.MkdirAll(backupDir(name), 0755); err != nil {
+		return err
+	}
+
+	meta := backupMeta{
+		Backup: Backup{
+			ID:        time.Now().UTC().Format(backupIDFormat),
+			Name:      name,
+			CreatedAt: time.Now(),
+		},
+	}
+	if local := FindLocalAddonByName(name); local != nil {
+		meta.Version = local.Version
+		meta.Addon = local
+	}
+
+	// Write under a temporary name so an interrupted backup is never listed
+	archive := backupArchivePath(name, meta.ID)
+	if err := util.ZipDir(addonPath, archive+".tmp", name); err != nil {
+		return fmt.Errorf("could not back up %s: %w", name, err)
+	}
+	if err := os.Rename(archive+".tmp", archive); err != nil {
+		_ = os.Remove(archive + ".tmp")
+		return err
+	}
+
+	if info, err := os.Stat(archive); err == nil {
+		meta.Size = info.Size()
+	}
+
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	if err := file.WriteJSON(backupMetaPath(name, meta.ID), data); err != nil {
+		_ = os.Remove(archive)
+		return err
+	}
+
+	logger.Info(name + " - Created backup " + meta.ID)
+	pruneAddonBackups(name, retention)
+	return nil
+}
+
+func readBackupMeta(name string, id string) (backupMeta, error) {
+	var meta backupMeta
+
+	data, err := os.ReadFile(backupMetaPath(name, id))
+	if err != nil {
+		return meta, err
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return meta, err
+	}
+
+	return meta, nil
+}
+
+// GetAddonBackups returns every backup of an addon, newest first.
+func GetAddonBackups(name string) []Backup {
+	if !isSafeBackupPathPart(name) {
+		return []Backup{}
+	}
+
+	entries, err := os.ReadDir(backupDir(name))
+	if err != nil {
+		return []Backup{}
+	}
+
+	backups := []Backup{}
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+
+		meta, err := readBackupMeta(name, strings.TrimSuffix(e.Name(), ".json"))
+		if err != nil {
+			continue
+		}
+		if !file.FileExists(backupArchivePath(name, meta.ID)) {
+			continue
+		}
+
+		backups = append(backups, meta.Backup)
+	}
+
+	sort.SliceStable(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+
+	return backups
+}
+
+func pruneAddonBackups(name string, retention int) {
+	backups := GetAddonBackups(name)
+	if len(backups) <= retention {
+		return
+	}
+
+	for _, b := range backups[retention:] {
+		if err := os.Remove(backupArchivePath(name, b.ID)); err != nil && !os.IsNotExist(err) {
+			logger.Error("Error removing addon backup:", err)
+		}
+		if err := os.Remove(backupMetaPath(name, b.ID)); err != nil && !os.IsNotExist(err) {
+			logger.Error("Error removing addon backup metadata:", err)
+		}
+	}
+}
+
+// RestoreAddonBackup puts back the files of an addon and its managed_addons.json entry as they were when
+// the backup was made.
+func RestoreAddonBackup(name string, backupID string) error {
+	if !isSafeBackupPathPart(name) {
+		return errors.New("invalid addon name")
+	}
+	if !isSafeBackupPathPart(backupID) {
+		return errors.New("invalid backup id")
+	}
+
+	meta, err := readBackupMeta(name, backupID)
+	if err != nil {
+		logger.Error(name+" - Error reading backup "+backupID+":", err)
+		return fmt.Errorf("backup %s of %s not found", backupID, name)
+	}
+
+	logger.Info(name + " - Restoring backup " + backupID)
+
+	cacheExtractDir := filepath.Join(config.GetCacheDir(), name)
+	_ = os.RemoveAll(cacheExtractDir)
+	if err := util.ExtractZip(backupArchivePath(name, backupID), cacheExtractDir); err != nil {
+		return err
+	}
+
+	addonFilesMu.Lock()
+	defer addonFilesMu.Unlock()
+
+	tx, err := beginAddonTransaction(name)
+	if err != nil {
+		return err
+	}
+
+	if err := restoreBackupSteps(tx, meta); err != nil {
+		logger.Error(name+" - Error restoring backup:", err)
+		tx.rollback()
+		return err
+	}
+
+	tx.commit()
+	logger.Info(name + " - Backup " + backupID + " restored")
+	return nil
+}
+
+func restoreBackupSteps(tx *addonTransaction, meta backupMeta) error {
+	// The backup already contains .data, so it replaces the current one
+	if err := tx.stage(false); err != nil {
+		return err
+	}
+
+	if err := tx.swap(); err != nil {
+		return err
+	}
+
+	if err := registerInAddonsTxt(meta.Name); err != nil {
+		return err
+	}
+
+	if meta.Addon != nil {
+		LocalAddons[meta.Name] = *meta.Addon
+	} else {
+		delete(LocalAddons, meta.Name)
+	}
+	return SaveManagedAddonsToDisk()
+}
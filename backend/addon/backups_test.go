@@ -0,0 +1,23 @@
+package addon
+
+import "testing"
+
+func TestIsSafeBackupPathPart(t *testing.T) {
+	tests := map[string]bool{
+		"":                    false,
+		".":                   false,
+		"..":                  false,
+		"../config":           false,
+		`..\config`:           false,
+		"a/b":                 false,
+		"My Addon":            false,
+		"Classic_Addon-2.0":   true,
+		"20240101-120000.000": true,
+		".hidden":             true,
+	}
+	for in, want := range tests {
+		if got := isSafeBackupPathPart(in); got != want {
+			t.Errorf("isSafeBackupPathPart(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package addon
+
+import (
+	"ClassicAddonManager/backend/api"
+	"ClassicAddonManager/backend/util"
+	"context"
+	"fmt"
+	"time"
+)
+
+// maxChangelogPages bounds how far back the release history is walked looking for the installed version.
+const maxChangelogPages = 10
+
+type ChangelogEntry struct {
+	Version     string    `json:"version"`
+	PublishedAt time.Time `json:"publishedAt"`
+	Body        string    `json:"body"`
+}
+
+type Changelog struct {
+	Name        string           `json:"name"`
+	FromVersion string           `json:"fromVersion"`
+	ToVersion   string           `json:"toVersion"`
+	Entries     []ChangelogEntry `json:"entries"`
+	// Complete is false when the installed version was not found in the release history,
+	// in which case Entries holds every release that was looked at.
+	Complete bool `json:"complete"`
+}
+
+// GetChangelog merges the notes of every release after the installed version of a managed addon
+// up to and including targetVersion ("" or "latest" for the newest release), newest first.
+func GetChangelog(ctx context.Context, name string, targetVersion string) (Changelog, error) {
+	local := FindLocalAddonByName(name)
+	if local == nil {
+		return Changelog{}, fmt.Errorf("%s is not a managed addon", name)
+	}
+
+	if targetVersion == "latest" {
+		targetVersion = ""
+	}
+
+	changelog := Changelog{
+		Name:        name,
+		FromVersion: local.Version,
+		ToVersion:   targetVersion,
+		Entries:     []ChangelogEntry{},
+	}
+
+	if targetVersion == local.Version {
+		changelog.Complete = true
+		return changelog, nil
+	}
+
+	// Releases are listed newest first, so collect from the target down to the installed version
+	collecting := targetVersion == ""
+	for page := 1; page <= maxChangelogPages; page++ {
+		releases, err := api.GetAddonReleases(ctx, name, page, 100)
+		if err != nil {
+			return Changelog{}, err
+		}
+
+		for _, release := range releases.Releases {
+			if release.TagName == local.Version {
+				changelog.Complete = true
+				return changelog, nil
+			}
+
+			if !collecting {
+				if release.TagName != targetVersion {
+					continue
+				}
+				collecting = true
+			}
+
+			if changelog.ToVersion == "" {
+				changelog.ToVersion = release.TagName
+			}
+
+			changelog.Entries = append(changelog.Entries, ChangelogEntry{
+				Version:     release.TagName,
+				PublishedAt: release.PublishedAt,
+				Body:        util.MarkdownToPlainText(release.Body),
+			})
+		}
+
+		if !releases.HasMore {
+			break
+		}
+	}
+
+	return changelog, nil
+}
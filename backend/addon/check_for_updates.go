@@ -4,6 +4,7 @@ import (
 	"ClassicAddonManager/backend/api"
 	"ClassicAddonManager/backend/config"
 	"ClassicAddonManager/backend/logger"
+	"context"
 	_ "embed"
 	"fmt"
 	"os"
@@ -14,7 +15,8 @@ import (
 //go:embed cam.lua
 var luaScript []byte
 
-func CheckForUpdates() map[string]Addon {
+// CheckForUpdates returns every managed addon with a newer release, skipping pinned addons and skipped versions.
+func CheckForUpdates(ctx context.Context) map[string]Addon {
 	err := LoadManagedAddonsFile()
 	if err != nil {
 		logger.Error("Error loading managed addons file:", err)
@@ -27,11 +29,15 @@ func CheckForUpdates() map[string]Addon {
 	)
 
 	for _, addon := range LocalAddons {
+		if addon.Pinned {
+			continue
+		}
+
 		wg.Add(1)
 		go func(a Addon) {
 			defer wg.Done()
 
-			release, err := api.GetAddonRelease(a.Name, "latest")
+			release, err := api.GetAddonRelease(ctx, a.Name, "latest", a.Channel)
 			if err != nil {
 				logger.Error("Error getting latest release for "+a.Name+":", err)
 				return
@@ -40,7 +46,7 @@ func CheckForUpdates() map[string]Addon {
 			mu.Lock()
 			defer mu.Unlock()
 
-			if release.TagName != a.Version {
+			if a.WantsUpdate(release) {
 				updatedAddon := a
 				updatedAddon.Version = release.TagName
 				updates[a.Name] = updatedAddon
@@ -0,0 +1,104 @@
+package addon
+
+import (
+	"ClassicAddonManager/backend/config"
+	"ClassicAddonManager/backend/file"
+	"ClassicAddonManager/backend/shared"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// FindConflictingAddons returns the installed addons that can't be installed alongside manifest, because
+// either of them lists the other in its conflicts. Addons manifest replaces are not included, installing it
+// migrates them instead.
+func FindConflictingAddons(manifest shared.AddonManifest) []string {
+	installed := GetAllAddonNames()
+
+	conflicting := make(map[string]struct{})
+	for _, name := range manifest.Conflicts {
+		if slices.Contains(installed, name) {
+			conflicting[name] = struct{}{}
+		}
+	}
+	for _, other := range cachedAddonManifest() {
+		if slices.Contains(other.Conflicts, manifest.Name) && slices.Contains(installed, other.Name) {
+			conflicting[other.Name] = struct{}{}
+		}
+	}
+
+	result := []string{}
+	for name := range conflicting {
+		if name != manifest.Name && !slices.Contains(manifest.Replaces, name) {
+			result = append(result, name)
+		}
+	}
+
+	sort.Strings(result)
+	return result
+}
+
+// findReplacedAddons returns the installed addons manifest replaces.
+func findReplacedAddons(manifest shared.AddonManifest) []string {
+	installed := GetAllAddonNames()
+
+	var replaced []string
+	for _, name := range manifest.Replaces {
+		if name != manifest.Name && slices.Contains(installed, name) {
+			replaced = append(replaced, name)
+		}
+	}
+	return replaced
+}
+
+// checkConflicts refuses to install an addon while addons it conflicts with are installed.
+func checkConflicts(manifest shared.AddonManifest) error {
+	conflicting := FindConflictingAddons(manifest)
+	if len(conflicting) > 0 {
+		return fmt.Errorf("%s conflicts with installed addon(s) %s", manifest.Name, strings.Join(conflicting, ", "))
+	}
+	return nil
+}
+
+// checkReplacedAddons refuses to install an addon when installed addons other than the ones it replaces
+// depend on an addon it replaces, as removing it would break them.
+func checkReplacedAddons(manifest shared.AddonManifest, replaced []string) error {
+	for _, oldName := range replaced {
+		var dependents []string
+		for _, dependent := range FindDependents(oldName) {
+			if dependent != manifest.Name && !slices.Contains(replaced, dependent) {
+				dependents = append(dependents, dependent)
+			}
+		}
+		if len(dependents) > 0 {
+			return fmt.Errorf("%s replaces %s, which %s depend on", manifest.Name, oldName, strings.Join(dependents, ", "))
+		}
+	}
+	return nil
+}
+
+// replacedDataDir returns the folder of the replaced addon that has a .data folder, which the replacing
+// addon takes over when it is staged, or "" when none has one. Installing is refused when more than one
+// has, as only one of them could be kept.
+func replacedDataDir(manifest shared.AddonManifest, replaced []string) (string, error) {
+	var dirs []string
+	var names []string
+	for _, oldName := range replaced {
+		dir := filepath.Join(config.GetAddonDir(), oldName)
+		if file.FileExists(filepath.Join(dir, ".data")) {
+			dirs = append(dirs, dir)
+			names = append(names, oldName)
+		}
+	}
+
+	switch len(dirs) {
+	case 0:
+		return "", nil
+	case 1:
+		return dirs[0], nil
+	default:
+		return "", fmt.Errorf("%s replaces %s, which all have saved data, and only one could be kept", manifest.Name, strings.Join(names, ", "))
+	}
+}
@@ -0,0 +1,46 @@
+package addon
+
+import (
+	"ClassicAddonManager/backend/shared"
+	"ClassicAddonManager/backend/util"
+	"strings"
+	"unicode"
+)
+
+// DependencySpec is one entry of AddonManifest.Dependencies: an addon name optionally followed by a
+// version constraint, as in "LibUI >=1.2 <2".
+type DependencySpec struct {
+	Name       string
+	Constraint util.VersionConstraint
+}
+
+// ParseDependency parses a dependency entry. The name ends at the first whitespace or operator character,
+// so "LibUI>=1.2" and "LibUI >=1.2" are the same entry. The name is returned even when the constraint is invalid.
+func ParseDependency(entry string) (DependencySpec, error) {
+	entry = strings.TrimSpace(entry)
+	name, constraint := entry, ""
+	if i := strings.IndexFunc(entry, func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune("=<>!^~", r)
+	}); i >= 0 {
+		name, constraint = entry[:i], entry[i:]
+	}
+
+	c, err := util.ParseVersionConstraint(constraint)
+	if err != nil {
+		return DependencySpec{Name: name}, err
+	}
+
+	return DependencySpec{Name: name, Constraint: c}, nil
+}
+
+// DependencyNames returns the names of the addons a manifest depends on, ignoring version constraints.
+func DependencyNames(manifest shared.AddonManifest) []string {
+	names := make([]string, 0, len(manifest.Dependencies))
+	for _, entry := range manifest.Dependencies {
+		spec, _ := ParseDependency(entry)
+		if spec.Name != "" {
+			names = append(names, spec.Name)
+		}
+	}
+	return names
+}
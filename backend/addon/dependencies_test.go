@@ -0,0 +1,52 @@
+package addon
+
+import (
+	"ClassicAddonManager/backend/shared"
+	"slices"
+	"testing"
+)
+
+func TestParseDependency(t *testing.T) {
+	tests := []struct {
+		entry      string
+		name       string
+		constraint string
+	}{
+		{"LibUI", "LibUI", ""},
+		{"  LibUI  ", "LibUI", ""},
+		{"LibUI >=1.2", "LibUI", ">=1.2"},
+		{"LibUI>=1.2", "LibUI", ">=1.2"},
+		{"LibUI>= 1.2 <2", "LibUI", ">=1.2 <2"},
+		{"LibUI\t^1.2", "LibUI", "^1.2"},
+		{"LibUI~1.2", "LibUI", "~1.2"},
+		{"LibUI!=1.2", "LibUI", "!=1.2"},
+		{"LibUI=1.2", "LibUI", "=1.2"},
+		{"Lib_UI-2 <2", "Lib_UI-2", "<2"},
+	}
+	for _, tt := range tests {
+		spec, err := ParseDependency(tt.entry)
+		if err != nil {
+			t.Errorf("ParseDependency(%q) = %v", tt.entry, err)
+			continue
+		}
+		if spec.Name != tt.name || spec.Constraint.String() != tt.constraint {
+			t.Errorf("ParseDependency(%q) = %q %q, want %q %q", tt.entry, spec.Name, spec.Constraint, tt.name, tt.constraint)
+		}
+	}
+
+	spec, err := ParseDependency("LibUI>=latest")
+	if err == nil {
+		t.Error("ParseDependency() of an invalid constraint succeeded")
+	}
+	if spec.Name != "LibUI" {
+		t.Errorf("name = %q, want LibUI", spec.Name)
+	}
+}
+
+func TestDependencyNames(t *testing.T) {
+	manifest := shared.AddonManifest{Dependencies: []string{"LibUI>=1.2", "LibMath ^2", "LibText", " ", "LibBad>=x"}}
+	want := []string{"LibUI", "LibMath", "LibText", "LibBad"}
+	if got := DependencyNames(manifest); !slices.Equal(got, want) {
+		t.Errorf("DependencyNames() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,171 @@
+package addon
+
+import (
+	"ClassicAddonManager/backend/config"
+	"ClassicAddonManager/backend/file"
+	"ClassicAddonManager/backend/logger"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+// Disabled addons keep their folder and managed_addons.json entry but are left out of addons.txt, so the
+// game doesn't load them. Their names are kept in disabled_addons.txt in the data dir.
+var (
+	disabledAddonNames       []string
+	disabledAddonNamesLoaded bool
+	disabledAddonNamesMu     sync.Mutex
+)
+
+func disabledAddonsPath() string {
+	return filepath.Join(config.GetDataDir(), "disabled_addons.txt")
+}
+
+// loadDisabledAddonNames reads disabled_addons.txt the first time it is needed. The caller holds disabledAddonNamesMu.
+func loadDisabledAddonNames() {
+	if disabledAddonNamesLoaded {
+		return
+	}
+	disabledAddonNamesLoaded = true
+
+	if !file.FileExists(disabledAddonsPath()) {
+		return
+	}
+
+	lines, err := file.ReadLines(disabledAddonsPath())
+	if err != nil {
+		logger.Error("Error reading disabled_addons.txt:", err)
+		return
+	}
+	disabledAddonNames = lines
+}
+
+// GetDisabledAddonNames returns the addons that are installed but disabled.
+func GetDisabledAddonNames() []string {
+	disabledAddonNamesMu.Lock()
+	defer disabledAddonNamesMu.Unlock()
+
+	loadDisabledAddonNames()
+	return slices.Clone(disabledAddonNames)
+}
+
+// IsDisabled reports whether an installed addon is disabled.
+func IsDisabled(name string) bool {
+	return slices.Contains(GetDisabledAddonNames(), name)
+}
+
+// GetAllAddonNames returns every installed addon, the enabled ones listed in addons.txt followed by the
+// disabled ones.
+func GetAllAddonNames() []string {
+	names := slices.Clone(GetInstalledAddonNames())
+	for _, name := range GetDisabledAddonNames() {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func setDisabled(name string, disabled bool) error {
+	disabledAddonNamesMu.Lock()
+	defer disabledAddonNamesMu.Unlock()
+
+	loadDisabledAddonNames()
+
+	names := slices.Clone(disabledAddonNames)
+	idx := slices.Index(names, name)
+	switch {
+	case disabled && idx < 0:
+		names = append(names, name)
+	case !disabled && idx >= 0:
+		names = slices.Delete(names, idx, idx+1)
+	default:
+		return nil
+	}
+
+	if err := file.WriteLines(disabledAddonsPath(), names); err != nil {
+		logger.Error("Error writing disabled_addons.txt:", err)
+		return err
+	}
+	disabledAddonNames = names
+	return nil
+}
+
+// restoreDisabledAddonNames writes names back to disabled_addons.txt, undoing the changes made since they
+// were read.
+func restoreDisabledAddonNames(names []string) error {
+	disabledAddonNamesMu.Lock()
+	defer disabledAddonNamesMu.Unlock()
+
+	if err := file.WriteLines(disabledAddonsPath(), names); err != nil {
+		logger.Error("Error restoring disabled_addons.txt:", err)
+		return err
+	}
+	disabledAddonNames = slices.Clone(names)
+	disabledAddonNamesLoaded = true
+	return nil
+}
+
+// SetEnabled disables an addon by removing it from addons.txt, or enables it again by adding it back. Its
+// folder and managed_addons.json entry are kept either way.
+func SetEnabled(name string, enabled bool) error {
+	addonFilesMu.Lock()
+	defer addonFilesMu.Unlock()
+
+	if !file.FileExists(filepath.Join(config.GetAddonDir(), name)) {
+		return fmt.Errorf("%s is not installed", name)
+	}
+
+	if enabled {
+		if !IsDisabled(name) {
+			return nil
+		}
+		if err := AddToAddonsTxt(name); err != nil {
+			return err
+		}
+		if err := setDisabled(name, false); err != nil {
+			_ = RemoveFromAddonsTxt(name)
+			return err
+		}
+		logger.Info("Enabled addon: " + name)
+		return nil
+	}
+
+	if !slices.Contains(GetInstalledAddonNames(), name) {
+		return fmt.Errorf("%s is not enabled", name)
+	}
+	if err := setDisabled(name, true); err != nil {
+		return err
+	}
+	if err := RemoveFromAddonsTxt(name); err != nil {
+		_ = setDisabled(name, false)
+		return err
+	}
+	logger.Info("Disabled addon: " + name)
+	return nil
+}
+
+// registerInAddonsTxt adds an addon that was just installed to addons.txt unless the user disabled it.
+func registerInAddonsTxt(name string) error {
+	if IsDisabled(name) {
+		return nil
+	}
+	return AddToAddonsTxt(name)
+}
+
+// renameInAddonLists puts newName in place of oldName in addons.txt, or in the disabled addons when oldName
+// is disabled. A newName that was already registered, as a replacing addon is, ends up in the same list.
+func renameInAddonLists(oldName string, newName string) error {
+	if !IsDisabled(oldName) {
+		return ReplaceInAddonsTxt(oldName, newName)
+	}
+
+	if err := setDisabled(newName, true); err != nil {
+		return err
+	}
+	if err := RemoveFromAddonsTxt(newName); err != nil {
+		return err
+	}
+	return setDisabled(oldName, false)
+}
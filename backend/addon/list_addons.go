@@ -5,13 +5,14 @@ import (
 	"strings"
 )
 
+// GetAddons lists the addons in addons.txt followed by the disabled ones.
 func GetAddons() []Addon {
-	names, err := ReadAddonsTxt()
-	if err != nil {
+	if _, err := ReadAddonsTxt(); err != nil {
 		logger.Error("Error reading addons.txt in GetAddons:", err)
 		return nil
 	}
 
+	names := GetAllAddonNames()
 	addons := make([]Addon, 0, len(names))
 	for _, name := range names {
 		addon := Addon{
@@ -24,8 +25,15 @@ func GetAddons() []Addon {
 			if addon.Alias == "" {
 				addon.Alias = strings.ReplaceAll(name, "_", " ")
 			}
+		} else if info, ok := readLuaAddonInfo(name); ok {
+			// Addons installed by hand or from a zip only describe themselves in their main.lua
+			addon.Alias = info.Name
+			addon.Author = info.Author
+			addon.Version = info.Version
+			addon.Description = info.Description
 		}
 
+		addon.Disabled = IsDisabled(name)
 		addons = append(addons, addon)
 	}
 	return addons
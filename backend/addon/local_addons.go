@@ -9,6 +9,7 @@ import (
 	"ClassicAddonManager/backend/util"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"slices"
@@ -27,6 +28,65 @@ type Addon struct {
 	IsManaged   bool      `json:"isManaged"`
 	UpdatedAt   time.Time `json:"updatedAt"`
 	Branch      string    `json:"branch,omitempty"`
+	// ArchiveHash is the SHA-256 of the release archive the addon was installed from.
+	ArchiveHash string `json:"archiveHash,omitempty"`
+	// Files maps every installed file outside .data to its SHA-256 at install time.
+	Files map[string]string `json:"files,omitempty"`
+	// Pinned addons are never reported as outdated.
+	Pinned bool `json:"pinned,omitempty"`
+	// SkippedVersion is an upstream release the user chose not to be notified about. On a branch channel
+	// it is a commit SHA.
+	SkippedVersion string `json:"skippedVersion,omitempty"`
+	// Channel is the release channel updates are taken from.
+	Channel api.Channel `json:"channel"`
+	// InstallReason records whether the user installed the addon or it was installed as a dependency.
+	// Entries from before it was recorded are treated as explicit installs.
+	InstallReason string `json:"installReason,omitempty"`
+	// Disabled is set by GetAddons for addons left out of addons.txt with SetEnabled. It isn't stored.
+	Disabled bool `json:"disabled,omitempty"`
+}
+
+// Reasons an addon was installed for.
+const (
+	InstallReasonExplicit   = "explicit"
+	InstallReasonDependency = "dependency"
+)
+
+// IsDependencyOnly reports whether the addon was only installed to satisfy another addon's dependencies.
+func (a Addon) IsDependencyOnly() bool {
+	return a.InstallReason == InstallReasonDependency
+}
+
+// releaseVersion returns what identifies release on the addon's channel: the commit for a branch head,
+// the tag otherwise.
+func (a Addon) releaseVersion(release api.Release) string {
+	if a.Channel.IsBranch() {
+		return release.Tag.Sha
+	}
+	return release.TagName
+}
+
+// installedVersion returns the installed version as compared against releaseVersion.
+func (a Addon) installedVersion() string {
+	if a.Channel.IsBranch() {
+		return a.Commit
+	}
+	return a.Version
+}
+
+// WantsUpdate reports whether the addon should be flagged for an update to release, honouring its pin
+// and skipped version. Addons tracking a branch compare commits instead of tags.
+func (a Addon) WantsUpdate(release api.Release) bool {
+	version := a.releaseVersion(release)
+	return version != "" && version != a.installedVersion() && !a.Pinned && version != a.SkippedVersion
+}
+
+// GetAddonChannel returns the release channel of an addon, stable for addons that aren't managed.
+func GetAddonChannel(name string) api.Channel {
+	if addon, exists := LocalAddons[name]; exists {
+		return addon.Channel
+	}
+	return api.Channel{}
 }
 
 var LocalAddons map[string]Addon
@@ -62,11 +122,14 @@ func FindLocalAddonByName(name string) *Addon {
 	return nil
 }
 
+// IsInstalled reports whether an addon is installed, enabled or not.
 func IsInstalled(name string) bool {
-	return slices.Contains(GetInstalledAddonNames(), name)
+	return slices.Contains(GetAllAddonNames(), name)
 }
 
-func AddManagedAddon(manifest shared.AddonManifest, release api.Release) {
+// AddManagedAddon records an installed release in managed_addons.json. An empty reason keeps the reason
+// already recorded for the addon, and a dependency install never demotes an addon the user installed.
+func AddManagedAddon(manifest shared.AddonManifest, release api.Release, archiveHash string, files map[string]string, reason string) error {
 	addon := Addon{
 		Name:        manifest.Name,
 		Description: manifest.Description,
@@ -77,6 +140,8 @@ func AddManagedAddon(manifest shared.AddonManifest, release api.Release) {
 		IsManaged:   true,
 		UpdatedAt:   release.PublishedAt,
 		Branch:      manifest.Branch,
+		ArchiveHash: archiveHash,
+		Files:       files,
 	}
 
 	if manifest.Alias == "" {
@@ -85,13 +150,75 @@ func AddManagedAddon(manifest shared.AddonManifest, release api.Release) {
 		addon.Alias = manifest.Alias
 	}
 
+	addon.InstallReason = reason
+	if addon.InstallReason == "" {
+		addon.InstallReason = InstallReasonExplicit
+	}
+
+	// Keep the choices the user made for this addon across updates
+	if previous, exists := LocalAddons[manifest.Name]; exists {
+		addon.Pinned = previous.Pinned
+		addon.Channel = previous.Channel
+		if previous.SkippedVersion != previous.releaseVersion(release) {
+			addon.SkippedVersion = previous.SkippedVersion
+		}
+		if reason == "" || (reason == InstallReasonDependency && !previous.IsDependencyOnly()) {
+			addon.InstallReason = previous.InstallReason
+		}
+	}
+
 	LocalAddons[manifest.Name] = addon
-	SaveManagedAddonsToDisk()
+	return SaveManagedAddonsToDisk()
+}
+
+// SetAddonPinned pins a managed addon to its installed version, or releases the pin.
+func SetAddonPinned(name string, pinned bool) error {
+	addon, exists := LocalAddons[name]
+	if !exists {
+		return fmt.Errorf("%s is not a managed addon", name)
+	}
+
+	addon.Pinned = pinned
+	LocalAddons[name] = addon
+	return SaveManagedAddonsToDisk()
+}
+
+// SetAddonChannel changes the release channel of a managed addon. It takes effect on the next update.
+func SetAddonChannel(name string, channel api.Channel) error {
+	if err := channel.Validate(); err != nil {
+		return err
+	}
+
+	addon, exists := LocalAddons[name]
+	if !exists {
+		return fmt.Errorf("%s is not a managed addon", name)
+	}
+
+	if channel.Kind == api.ChannelStable {
+		channel.Kind = ""
+	}
+	addon.Channel = channel
+	// A skipped tag means nothing on a branch and the other way around
+	addon.SkippedVersion = ""
+	LocalAddons[name] = addon
+	return SaveManagedAddonsToDisk()
+}
+
+// SetSkippedVersion ignores one upstream release of a managed addon. An empty version clears it.
+func SetSkippedVersion(name string, version string) error {
+	addon, exists := LocalAddons[name]
+	if !exists {
+		return fmt.Errorf("%s is not a managed addon", name)
+	}
+
+	addon.SkippedVersion = version
+	LocalAddons[name] = addon
+	return SaveManagedAddonsToDisk()
 }
 
 func RemoveManagedAddon(name string) bool {
 	delete(LocalAddons, name)
-	SaveManagedAddonsToDisk()
+	_ = SaveManagedAddonsToDisk()
 	// Check if the addon is still in LocalAddons
 	if _, exists := LocalAddons[name]; exists {
 		return false
@@ -99,7 +226,7 @@ func RemoveManagedAddon(name string) bool {
 	return true
 }
 
-func SaveManagedAddonsToDisk() {
+func SaveManagedAddonsToDisk() error {
 	managedAddons := make([]Addon, 0, len(LocalAddons))
 	for _, addon := range LocalAddons {
 		managedAddons = append(managedAddons, addon)
@@ -108,17 +235,18 @@ func SaveManagedAddonsToDisk() {
 	data, err := json.Marshal(managedAddons)
 	if err != nil {
 		logger.Error("Error marshalling managed addons:", err)
-		return
+		return err
 	}
 
 	fp := filepath.Join(config.GetDataDir(), "managed_addons.json")
 	err = os.WriteFile(fp, data, 0644)
 	if err != nil {
 		logger.Error("Error writing managed addons to disk:", err)
-		return
+		return err
 	}
 
 	logger.Info("Managed addons saved to disk")
+	return nil
 }
 
 func InstallZip(zipPath string) (string, error) {
@@ -145,15 +273,28 @@ func InstallZip(zipPath string) (string, error) {
 		return "", err
 	}
 
-	// Move the extracted addon from cache to the addon directory
-	if !util.MoveAddonRelease(addonName) {
-		return "", errors.New("failed to move addon release")
+	// Swap the extracted addon into the addon directory
+	addonFilesMu.Lock()
+	defer addonFilesMu.Unlock()
+
+	tx, err := beginAddonTransaction(addonName)
+	if err != nil {
+		return "", err
 	}
 
-	if err := AddToAddonsTxt(addonName); err != nil {
-		logger.Error("Error adding addon to addons.txt:", err)
+	err = tx.stage(false)
+	if err == nil {
+		err = tx.swap()
+	}
+	if err == nil {
+		err = registerInAddonsTxt(addonName)
+	}
+	if err != nil {
+		logger.Error("Error installing zip addon:", err)
+		tx.rollback()
 		return "", err
 	}
+	tx.commit()
 
 	return addonName, nil
 }
@@ -0,0 +1,62 @@
+package addon
+
+import (
+	"ClassicAddonManager/backend/config"
+	"ClassicAddonManager/backend/logger"
+	"ClassicAddonManager/backend/util"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// maxMainLuaSize bounds how much of a main.lua is read when looking for its addon info table.
+const maxMainLuaSize = 1 << 20
+
+// luaInfoCacheEntry is the parsed main.lua of an addon, valid while the file keeps its modification time.
+type luaInfoCacheEntry struct {
+	modTime time.Time
+	info    util.LuaAddonInfo
+	ok      bool
+}
+
+var (
+	luaInfoCache   = make(map[string]luaInfoCacheEntry)
+	luaInfoCacheMu sync.Mutex
+)
+
+// readLuaAddonInfo returns the info table declared by the main.lua of an installed addon. ok is false when
+// the addon has no main.lua or it declares no such table.
+func readLuaAddonInfo(name string) (util.LuaAddonInfo, bool) {
+	path := filepath.Join(config.GetAddonDir(), name, "main.lua")
+
+	stat, err := os.Stat(path)
+	if err != nil {
+		return util.LuaAddonInfo{}, false
+	}
+
+	luaInfoCacheMu.Lock()
+	defer luaInfoCacheMu.Unlock()
+
+	if entry, ok := luaInfoCache[name]; ok && entry.modTime.Equal(stat.ModTime()) {
+		return entry.info, entry.ok
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		logger.Warn(name + " - Could not read main.lua: " + err.Error())
+		return util.LuaAddonInfo{}, false
+	}
+	defer f.Close()
+
+	src, err := io.ReadAll(io.LimitReader(f, maxMainLuaSize))
+	if err != nil {
+		logger.Warn(name + " - Could not read main.lua: " + err.Error())
+		return util.LuaAddonInfo{}, false
+	}
+
+	info, ok := util.ParseLuaAddonInfo(string(src))
+	luaInfoCache[name] = luaInfoCacheEntry{modTime: stat.ModTime(), info: info, ok: ok}
+	return info, ok
+}
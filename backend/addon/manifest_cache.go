@@ -0,0 +1,151 @@
+package addon
+
+import (
+	"ClassicAddonManager/backend/api"
+	"ClassicAddonManager/backend/config"
+	"ClassicAddonManager/backend/file"
+	"ClassicAddonManager/backend/logger"
+	"ClassicAddonManager/backend/shared"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// manifestRevalidateInterval is how long a cached manifest is served before asking the registry again.
+const manifestRevalidateInterval = time.Minute
+
+const manifestCacheFileName = "manifest.json"
+
+type ManifestStatus struct {
+	Stale     bool      `json:"stale"`
+	FetchedAt time.Time `json:"fetchedAt"`
+}
+
+type manifestCacheFile struct {
+	Validators api.CacheValidators    `json:"validators"`
+	FetchedAt  time.Time              `json:"fetchedAt"`
+	Addons     []shared.AddonManifest `json:"addons"`
+}
+
+var (
+	manifestCache     manifestCacheFile
+	manifestCheckedAt time.Time
+	manifestStale     bool
+	manifestLoaded    bool
+	manifestMu        sync.Mutex
+)
+
+// GetAddonManifest returns every addon listed by the active registry. The manifest is kept in the cache
+// dir and revalidated with ETag/If-Modified-Since, and the last good copy is served while the registry
+// is unreachable. The registry is asked without holding manifestMu, so readers of the cached copy never
+// wait on the network.
+func GetAddonManifest(ctx context.Context) []shared.AddonManifest {
+	manifestMu.Lock()
+	if !manifestLoaded {
+		loadManifestCache()
+		manifestLoaded = true
+	}
+	cached := manifestCache
+	checkedAt := manifestCheckedAt
+	manifestMu.Unlock()
+
+	if len(cached.Addons) > 0 && time.Since(checkedAt) < manifestRevalidateInterval {
+		return cached.Addons
+	}
+
+	var validators api.CacheValidators
+	if len(cached.Addons) > 0 {
+		validators = cached.Validators
+	}
+
+	response, err := api.GetAddonManifest(ctx, validators)
+
+	manifestMu.Lock()
+	defer manifestMu.Unlock()
+
+	manifestCheckedAt = time.Now()
+	if err != nil {
+		if len(manifestCache.Addons) == 0 {
+			logger.Error("GetAddonManifest Error:", err)
+			return []shared.AddonManifest{}
+		}
+		manifestStale = true
+		logger.Warn("Registry unreachable, serving cached addon manifest from " + manifestCache.FetchedAt.Format(time.RFC3339))
+		return manifestCache.Addons
+	}
+
+	manifestStale = false
+	if response.NotModified {
+		return manifestCache.Addons
+	}
+
+	manifestCache = manifestCacheFile{
+		Validators: response.Validators,
+		FetchedAt:  time.Now(),
+		Addons:     response.Addons,
+	}
+	saveManifestCache()
+
+	logger.Info("Retrieved " + strconv.Itoa(len(response.Addons)) + " addon manifests from remote source")
+
+	return manifestCache.Addons
+}
+
+// cachedAddonManifest returns the last manifest fetched from the registry without contacting it.
+func cachedAddonManifest() []shared.AddonManifest {
+	manifestMu.Lock()
+	defer manifestMu.Unlock()
+
+	if !manifestLoaded {
+		loadManifestCache()
+		manifestLoaded = true
+	}
+	return manifestCache.Addons
+}
+
+// GetManifestStatus reports whether the manifest returned by GetAddonManifest is a stale offline copy.
+func GetManifestStatus() ManifestStatus {
+	manifestMu.Lock()
+	defer manifestMu.Unlock()
+	return ManifestStatus{
+		Stale:     manifestStale,
+		FetchedAt: manifestCache.FetchedAt,
+	}
+}
+
+func loadManifestCache() {
+	fp := filepath.Join(config.GetCacheDir(), manifestCacheFileName)
+	if !file.FileExists(fp) {
+		return
+	}
+
+	data, err := os.ReadFile(fp)
+	if err != nil {
+		logger.Error("Error reading cached addon manifest:", err)
+		return
+	}
+
+	var cached manifestCacheFile
+	if err := json.Unmarshal(data, &cached); err != nil {
+		logger.Error("Error parsing cached addon manifest:", err)
+		return
+	}
+
+	manifestCache = cached
+}
+
+func saveManifestCache() {
+	data, err := json.Marshal(manifestCache)
+	if err != nil {
+		logger.Error("Error marshalling addon manifest cache:", err)
+		return
+	}
+
+	if err := file.WriteJSON(filepath.Join(config.GetCacheDir(), manifestCacheFileName), data); err != nil {
+		logger.Error("Error writing addon manifest cache:", err)
+	}
+}
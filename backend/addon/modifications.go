@@ -0,0 +1,154 @@
+package addon
+
+import (
+	"ClassicAddonManager/backend/config"
+	"ClassicAddonManager/backend/file"
+	"ClassicAddonManager/backend/logger"
+	"ClassicAddonManager/backend/util"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Modification modes, deciding what an update does with files of an addon that were changed or added since
+// it was installed. Files covered by preserve rules are kept regardless.
+const (
+	// ModificationsAbort refuses to update an addon with local modifications. It is the default.
+	ModificationsAbort = ""
+	// ModificationsBackup saves the modified files to an archive in the addon's backup folder, then updates.
+	ModificationsBackup = "backup"
+	// ModificationsCarryOver copies the modified files whole over the new release, replacing its copies.
+	ModificationsCarryOver = "carry"
+)
+
+// GetLocalModifications compares the files of a managed addon with the hashes recorded when it was
+// installed. Modified and Added are what an update would overwrite or drop.
+func GetLocalModifications(name string) VerificationResult {
+	return VerifyInstalledAddon(name)
+}
+
+// changedFiles returns the modified and added files of a verification, sorted.
+func changedFiles(result VerificationResult) []string {
+	changed := append(append([]string{}, result.Modified...), result.Added...)
+	sort.Strings(changed)
+	return changed
+}
+
+// CheckLocalModifications returns the error an update of name would be refused with in mode, without
+// changing anything.
+func CheckLocalModifications(name string, mode string) error {
+	switch mode {
+	case ModificationsBackup, ModificationsCarryOver:
+		return nil
+	case ModificationsAbort:
+		_, err := handleLocalModifications(name, mode)
+		return err
+	default:
+		return fmt.Errorf("unknown modification mode %s", mode)
+	}
+}
+
+// handleLocalModifications applies mode to the local modifications of an addon about to be updated and
+// returns the files to carry over to the new release. Addons without recorded hashes are updated as before.
+func handleLocalModifications(name string, mode string) ([]string, error) {
+	result := GetLocalModifications(name)
+	if !result.Recorded || result.Error != "" {
+		return nil, nil
+	}
+
+	changed := changedFiles(result)
+	if len(changed) == 0 {
+		return nil, nil
+	}
+
+	switch mode {
+	case ModificationsAbort:
+		return nil, fmt.Errorf("%s has local modifications to %s", name, strings.Join(changed, ", "))
+	case ModificationsBackup:
+		return nil, backupModifiedFiles(name, changed)
+	case ModificationsCarryOver:
+		logger.Info(name + " - Carrying over local modifications to " + strings.Join(changed, ", "))
+		return changed, nil
+	default:
+		return nil, fmt.Errorf("unknown modification mode %s", mode)
+	}
+}
+
+// modifiedArchiveSuffix ends the name of the archives backupModifiedFiles writes.
+const modifiedArchiveSuffix = "-modified.zip"
+
+// backupModifiedFiles archives the given files of an addon next to its backups. These archives only hold
+// the changed files, so they are not listed as backups that can be restored, but they are pruned to the
+// same retention. One is always kept, as the user asked for it even when backups are disabled.
+func backupModifiedFiles(name string, files []string) error {
+	if err := os.MkdirAll(backupDir(name), 0755); err != nil {
+		return err
+	}
+
+	archive := filepath.Join(backupDir(name), time.Now().UTC().Format(backupIDFormat)+modifiedArchiveSuffix)
+	if err := util.ZipFiles(filepath.Join(config.GetAddonDir(), name), files, archive, name); err != nil {
+		return fmt.Errorf("could not back up local modifications of %s: %w", name, err)
+	}
+
+	logger.Info(name + " - Saved local modifications to " + archive)
+	pruneModifiedArchives(name, max(GetBackupRetention(), 1))
+	return nil
+}
+
+// pruneModifiedArchives removes all but the newest retention archives of modified files of an addon. Their
+// names start with the time they were made, so they sort oldest first.
+func pruneModifiedArchives(name string, retention int) {
+	archives, err := filepath.Glob(filepath.Join(backupDir(name), "*"+modifiedArchiveSuffix))
+	if err != nil || len(archives) <= retention {
+		return
+	}
+
+	sort.Strings(archives)
+	for _, archive := range archives[:len(archives)-retention] {
+		if err := os.Remove(archive); err != nil && !os.IsNotExist(err) {
+			logger.Error("Error removing archive of modified files:", err)
+		}
+	}
+}
+
+// carryOverModified copies the given files of the installed addon over the staged release. Whole files are
+// copied rather than a diff, so the local copy wins over any change the release makes to the same file. The hashes the
+// release ships for them, empty for files it doesn't ship, are returned so they can be recorded in place of
+// the carried over ones, which keeps them reported as local modifications.
+func carryOverModified(t *addonTransaction, files []string) (map[string]string, error) {
+	releaseHashes := make(map[string]string, len(files))
+
+	for _, rel := range files {
+		src := filepath.Join(t.addonDir, filepath.FromSlash(rel))
+		dest := filepath.Join(t.stagingDir, filepath.FromSlash(rel))
+
+		releaseHashes[rel] = ""
+		if file.FileExists(dest) {
+			hash, err := util.HashFile(dest)
+			if err != nil {
+				return nil, err
+			}
+			releaseHashes[rel] = hash
+
+			if t.previousManaged != nil && t.previousManaged.Files[rel] != "" && t.previousManaged.Files[rel] != hash {
+				logger.Warn(t.name + " - The new release also changes " + rel + ", keeping the local version")
+			}
+		}
+
+		if err := os.MkdirAll(filepath.Dir(dest), os.ModePerm); err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(src)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(dest, data, 0644); err != nil {
+			return nil, fmt.Errorf("could not carry over %s: %w", rel, err)
+		}
+	}
+
+	return releaseHashes, nil
+}
@@ -0,0 +1,79 @@
+package addon
+
+import (
+	"ClassicAddonManager/backend/shared"
+	"slices"
+	"sort"
+)
+
+// FindOrphanedAddons returns the installed addons that were only installed as a dependency and that no
+// other installed addon needs any more, directly or through other dependencies.
+func FindOrphanedAddons() []string {
+	installed := GetAllAddonNames()
+
+	manifestByName := make(map[string]shared.AddonManifest)
+	for _, manifest := range cachedAddonManifest() {
+		manifestByName[manifest.Name] = manifest
+	}
+
+	// Everything reachable from an addon the user chose, managed or not, is still needed
+	needed := make(map[string]struct{})
+	var walk func(name string)
+	walk = func(name string) {
+		if _, ok := needed[name]; ok {
+			return
+		}
+		needed[name] = struct{}{}
+		for _, dependency := range DependencyNames(manifestByName[name]) {
+			walk(dependency)
+		}
+	}
+
+	for _, name := range installed {
+		if local, exists := LocalAddons[name]; exists && local.IsDependencyOnly() {
+			continue
+		}
+		walk(name)
+	}
+
+	orphans := []string{}
+	for _, name := range installed {
+		local, exists := LocalAddons[name]
+		if !exists || !local.IsDependencyOnly() {
+			continue
+		}
+		if _, ok := needed[name]; !ok {
+			orphans = append(orphans, name)
+		}
+	}
+
+	sort.Strings(orphans)
+	return orphans
+}
+
+// RemoveOrphanedAddons uninstalls the given addons, which should come from FindOrphanedAddons. Addons that
+// are no longer orphaned by the time this runs are left alone.
+func RemoveOrphanedAddons(names []string) shared.UninstallResult {
+	result := shared.UninstallResult{
+		Success:    true,
+		Dependents: []string{},
+		Removed:    []string{},
+		Unmanaged:  []string{},
+	}
+
+	orphans := FindOrphanedAddons()
+	for _, name := range names {
+		if !slices.Contains(orphans, name) {
+			continue
+		}
+
+		if err := removeAddon(name); err != nil {
+			result.Success = false
+			result.Error = err.Error()
+			return result
+		}
+		result.Removed = append(result.Removed, name)
+	}
+
+	return result
+}
@@ -0,0 +1,180 @@
+package addon
+
+import (
+	"ClassicAddonManager/backend/config"
+	"ClassicAddonManager/backend/file"
+	"ClassicAddonManager/backend/logger"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// metadataFileName is the optional file an addon can ship at the root of its release to describe how it
+// is installed, as in {"preserve": ["settings.lua", "profiles/"]}.
+const metadataFileName = ".cam.json"
+
+// addonMetadata is the content of metadataFileName.
+type addonMetadata struct {
+	// Preserve lists paths relative to the addon folder that hold user data and are kept on update. A
+	// trailing slash marks a folder, and entries may use path.Match wildcards.
+	Preserve []string `json:"preserve"`
+}
+
+func readAddonMetadata(dir string) (addonMetadata, error) {
+	var metadata addonMetadata
+
+	data, err := os.ReadFile(filepath.Join(dir, metadataFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return metadata, nil
+		}
+		return metadata, err
+	}
+
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return metadata, fmt.Errorf("invalid %s: %w", metadataFileName, err)
+	}
+	return metadata, nil
+}
+
+// preserveConfigKey holds the preserve rule overrides in config.toml, as in
+//
+//	[[preserve]]
+//	addon = "My.Addon"
+//	paths = ["settings.lua"]
+//
+// An array of tables is used rather than a table keyed by addon name because viper splits keys on dots,
+// which addon names may contain.
+const preserveConfigKey = "preserve"
+
+type preserveOverride struct {
+	Addon string   `mapstructure:"addon"`
+	Paths []string `mapstructure:"paths"`
+}
+
+func readPreserveOverrides() []preserveOverride {
+	var overrides []preserveOverride
+	if err := config.UnmarshalKey(preserveConfigKey, &overrides); err != nil {
+		logger.Warn("Ignoring preserve rules in config: " + err.Error())
+		return nil
+	}
+	return overrides
+}
+
+// GetPreserveRules returns the preserve rules of an addon besides .data: the override from config.toml
+// when there is one, otherwise the rules shipped in dir, the addon's folder or a staged release of it.
+func GetPreserveRules(name string, dir string) []string {
+	for _, override := range readPreserveOverrides() {
+		if strings.EqualFold(override.Addon, name) {
+			return override.Paths
+		}
+	}
+
+	metadata, err := readAddonMetadata(dir)
+	if err != nil {
+		logger.Warn(name + " - Ignoring preserve rules: " + err.Error())
+		return nil
+	}
+	return metadata.Preserve
+}
+
+// SetPreserveRules overrides the preserve rules shipped with an addon in config.toml. An empty list removes
+// the override, so the rules the addon ships apply again.
+func SetPreserveRules(name string, rules []string) {
+	entries := []map[string]any{}
+	for _, override := range readPreserveOverrides() {
+		if !strings.EqualFold(override.Addon, name) {
+			entries = append(entries, map[string]any{"addon": override.Addon, "paths": override.Paths})
+		}
+	}
+	if len(rules) > 0 {
+		entries = append(entries, map[string]any{"addon": name, "paths": rules})
+	}
+
+	config.Set(preserveConfigKey, entries)
+}
+
+// matchesPreserveRule reports whether a slash separated path relative to an addon folder is covered by one
+// of the rules.
+func matchesPreserveRule(rel string, rules []string) bool {
+	for _, rule := range rules {
+		rule = strings.Trim(filepath.ToSlash(strings.TrimSpace(rule)), "/")
+		if rule == "" {
+			continue
+		}
+		if rel == rule || strings.HasPrefix(rel, rule+"/") {
+			return true
+		}
+		if ok, _ := path.Match(rule, rel); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// installedPreserveRules returns the preserve rules of an installed addon, including .data.
+func installedPreserveRules(name string) []string {
+	return append([]string{".data"}, GetPreserveRules(name, filepath.Join(config.GetAddonDir(), name))...)
+}
+
+// carryOverPreserved copies the files and folders of the installed addon in addonDir matching rules into
+// the staged release in stagingDir, replacing what the release ships there. It returns the preserved paths
+// the release shipped its own copy of, which was overwritten.
+func carryOverPreserved(name string, addonDir string, stagingDir string, rules []string) ([]string, error) {
+	if len(rules) == 0 || !file.FileExists(addonDir) {
+		return nil, nil
+	}
+
+	var overwritten []string
+	err := filepath.WalkDir(addonDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(addonDir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == "." || !matchesPreserveRule(rel, rules) {
+			return nil
+		}
+
+		dest := filepath.Join(stagingDir, filepath.FromSlash(rel))
+		if file.FileExists(dest) {
+			logger.Warn(name + " - The new release ships " + rel + ", which is preserved, keeping the installed copy")
+			overwritten = append(overwritten, rel)
+			if err := os.RemoveAll(dest); err != nil {
+				return err
+			}
+		}
+
+		if d.IsDir() {
+			if err := file.CopyDir(p, dest); err != nil {
+				return fmt.Errorf("could not carry over %s: %w", rel, err)
+			}
+			return filepath.SkipDir
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		if err := os.MkdirAll(filepath.Dir(dest), os.ModePerm); err != nil {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(dest, data, fs.FileMode(0644)); err != nil {
+			return fmt.Errorf("could not carry over %s: %w", rel, err)
+		}
+		return nil
+	})
+	return overwritten, err
+}
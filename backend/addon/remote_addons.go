@@ -7,34 +7,56 @@ import (
 	"ClassicAddonManager/backend/logger"
 	"ClassicAddonManager/backend/shared"
 	"ClassicAddonManager/backend/util"
-	"encoding/json"
-	"errors"
-	"fmt"
-	"net/http"
+	"context"
 	"os"
 	"path/filepath"
-	"strconv"
 )
 
-func InstallAddon(manifest shared.AddonManifest, version string) (bool, error) {
+// downloadProgressHandler receives progress of every addon archive download. It is set once at startup.
+var downloadProgressHandler func(shared.DownloadProgress)
+
+// SetDownloadProgressHandler sets the function that receives addon download progress.
+func SetDownloadProgressHandler(handler func(shared.DownloadProgress)) {
+	downloadProgressHandler = handler
+}
+
+// InstallAddon installs an addon, replacing any existing folder of the same name. reason records whether the
+// user picked the addon or it was pulled in as a dependency. Installing an addon that conflicts with an
+// installed one is refused, and installed addons it replaces hand it their .data folder and addons.txt entry.
+// Replacing an addon other installed addons depend on is refused too, as is replacing more than one addon
+// with a .data folder.
+func InstallAddon(ctx context.Context, manifest shared.AddonManifest, version string, reason string) (bool, error) {
 	ensureAddonsTxtExists()
 
 	logger.Info("Installing addon:" + manifest.Name + " from " + manifest.Repo + " version: " + version)
 
-	if err := downloadAndExtractAddon(manifest, version); err != nil {
+	if err := checkConflicts(manifest); err != nil {
+		logger.Error(manifest.Name+" - Refusing install:", err)
 		return false, err
 	}
 
-	if !util.MoveAddonRelease(manifest.Name) {
-		logger.Error(manifest.Name+" - Error moving addon release", errors.New("error moving addon release"))
-		return false, nil
+	replaced := findReplacedAddons(manifest)
+	if err := checkReplacedAddons(manifest, replaced); err != nil {
+		logger.Error(manifest.Name+" - Refusing install:", err)
+		return false, err
+	}
+	dataFrom, err := replacedDataDir(manifest, replaced)
+	if err != nil {
+		logger.Error(manifest.Name+" - Refusing install:", err)
+		return false, err
 	}
 
-	if err := AddToAddonsTxt(manifest.Name); err != nil {
+	release, archiveHash, err := downloadAndExtractAddon(ctx, manifest, version)
+	if err != nil {
 		return false, err
 	}
 
-	if err := updateAddonMetadata(manifest, version); err != nil {
+	if err := checkReleaseAgainstDependents(manifest.Name, release); err != nil {
+		logger.Error(manifest.Name+" - Refusing release:", err)
+		return false, err
+	}
+
+	if _, err := applyRelease(manifest, release, archiveHash, false, reason, nil, dataFrom, replaced); err != nil {
 		return false, err
 	}
 
@@ -42,183 +64,198 @@ func InstallAddon(manifest shared.AddonManifest, version string) (bool, error) {
 	return true, nil
 }
 
-// UpdateAddon updates an existing addon by replacing all files except the persistent .data folder.
-func UpdateAddon(manifest shared.AddonManifest, version string) (bool, error) {
+// UpdateAddon updates an existing addon by replacing all files except the persistent .data folder and the
+// paths its preserve rules cover, and returns the preserved paths the release shipped its own copy of.
+// The current folder is backed up first so the update can be undone with RestoreAddonBackup. Files changed
+// since the addon was installed are handled according to mode.
+func UpdateAddon(ctx context.Context, manifest shared.AddonManifest, version string, mode string) ([]string, error) {
 	ensureAddonsTxtExists()
 
 	logger.Info("Updating addon:" + manifest.Name + " from " + manifest.Repo + " version: " + version)
 
-	if err := downloadAndExtractAddon(manifest, version); err != nil {
-		return false, err
+	carryOver, err := handleLocalModifications(manifest.Name, mode)
+	if err != nil {
+		logger.Error(manifest.Name+" - Refusing update:", err)
+		return nil, err
 	}
 
-	if err := performUpdateFileOperations(manifest); err != nil {
-		return false, err
+	release, archiveHash, err := downloadAndExtractAddon(ctx, manifest, version)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := AddToAddonsTxt(manifest.Name); err != nil {
-		return false, err
+	if err := checkReleaseAgainstDependents(manifest.Name, release); err != nil {
+		logger.Error(manifest.Name+" - Refusing release:", err)
+		return nil, err
 	}
 
-	if err := updateAddonMetadata(manifest, version); err != nil {
-		return false, err
+	if err := createAddonBackup(manifest.Name); err != nil {
+		logger.Error(manifest.Name+" - Error backing up addon, aborting update:", err)
+		return nil, err
 	}
 
-	logger.Info(manifest.Name + " updated successfully")
-	return true, nil
-}
-
-// GetAddonManifest https://aac.gaijin.dev/addons
-func GetAddonManifest() []shared.AddonManifest {
-	req, err := http.NewRequest("GET", "https://aac.gaijin.dev/addons", nil)
+	overwritten, err := applyRelease(manifest, release, archiveHash, true, "", carryOver, "", nil)
 	if err != nil {
-		logger.Error("GetAddonManifest Error:", err)
-		return []shared.AddonManifest{}
+		return nil, err
 	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		logger.Error("GetAddonManifest Error:", err)
-		return []shared.AddonManifest{}
-	}
-	defer resp.Body.Close()
+	logger.Info(manifest.Name + " updated successfully")
+	return overwritten, nil
+}
 
-	if resp.StatusCode != 200 {
-		logger.Error("GetAddonManifest Error: Status Code", errors.New(strconv.Itoa(resp.StatusCode)))
-		return []shared.AddonManifest{}
+// applyRelease swaps the release extracted into the cache dir into the addon directory and registers it in
+// addons.txt and managed_addons.json. If any step fails, all of them are undone. An empty reason keeps the
+// install reason already recorded. carryOver are locally modified files kept over the release, dataFrom
+// is the folder of a replaced addon whose .data folder a new install takes over, and replaced are the
+// installed addons the release takes the place of, which are removed with it. It returns the preserved
+// paths the release shipped, which were replaced by the installed copy.
+func applyRelease(manifest shared.AddonManifest, release api.Release, archiveHash string, keepData bool, reason string, carryOver []string, dataFrom string, replaced []string) ([]string, error) {
+	addonFilesMu.Lock()
+	defer addonFilesMu.Unlock()
+
+	tx, err := beginAddonTransaction(manifest.Name)
+	if err != nil {
+		return nil, err
 	}
-
-	var manifests []shared.AddonManifest
-	if err := json.NewDecoder(resp.Body).Decode(&manifests); err != nil {
-		logger.Error("GetAddonManifest Error:", err)
-		return []shared.AddonManifest{}
+	tx.carryOver = carryOver
+	tx.dataFrom = dataFrom
+	tx.replaced = replaced
+
+	if err := applyReleaseSteps(tx, manifest, release, archiveHash, keepData, reason); err != nil {
+		logger.Error(manifest.Name+" - Error applying release:", err)
+		tx.rollback()
+		return nil, err
 	}
 
-	logger.Info("Retrieved " + strconv.Itoa(len(manifests)) + " addon manifests from remote source")
-
-	return manifests
+	tx.commit()
+	return tx.overwritten, nil
 }
 
-func ensureAddonsTxtExists() {
-	if !file.FileExists(filepath.Join(config.GetAddonDir(), "addons.txt")) {
-		logger.Info("addons.txt not found in AAC path, creating it.")
-		CreateAddonsTxt()
+func applyReleaseSteps(tx *addonTransaction, manifest shared.AddonManifest, release api.Release, archiveHash string, keepData bool, reason string) error {
+	if err := tx.stage(keepData); err != nil {
+		return err
 	}
-}
 
-func buildDownloadURL(manifest shared.AddonManifest, version string) string {
-	if version == "" || version == "latest" {
-		return fmt.Sprintf("/addon/%s/download", manifest.Name)
+	if err := tx.swap(); err != nil {
+		return err
 	}
-	return fmt.Sprintf("/addon/%s/download?version=%s", manifest.Name, version)
-}
 
-func downloadAndExtractAddon(manifest shared.AddonManifest, version string) error {
-	zipName := manifest.Name + ".zip"
-	url := buildDownloadURL(manifest, version)
-
-	if err := util.DownloadFile(api.ApiURL+url, filepath.Join(config.GetCacheDir(), zipName)); err != nil {
+	if err := registerInAddonsTxt(manifest.Name); err != nil {
 		return err
 	}
 
-	if file.FileExists(filepath.Join(config.GetCacheDir(), manifest.Name)) {
-		if err := os.RemoveAll(filepath.Join(config.GetCacheDir(), manifest.Name)); err != nil {
-			return err
+	files, err := hashInstalledFiles(manifest.Name)
+	if err != nil {
+		logger.Error(manifest.Name+" - Error hashing installed files:", err)
+	}
+	// Record what the release shipped for carried over files so they are still seen as modified
+	for rel, hash := range tx.carriedHashes {
+		if hash == "" {
+			delete(files, rel)
+		} else if files != nil {
+			files[rel] = hash
 		}
 	}
 
-	if err := util.ExtractAddonRelease(zipName, manifest.Name); err != nil {
+	if err := AddManagedAddon(manifest, release, archiveHash, files, reason); err != nil {
 		return err
 	}
 
-	return os.Remove(filepath.Join(config.GetCacheDir(), zipName))
+	return tx.migrateReplaced()
 }
 
-func updateAddonMetadata(manifest shared.AddonManifest, version string) error {
-	if version == "" {
-		version = "latest"
+func ensureAddonsTxtExists() {
+	if !file.FileExists(filepath.Join(config.GetAddonDir(), "addons.txt")) {
+		logger.Info("addons.txt not found in AAC path, creating it.")
+		CreateAddonsTxt()
 	}
+}
 
-	release, err := api.GetAddonRelease(manifest.Name, version)
+// downloadAndExtractAddon resolves the requested release, downloads its archive into the archive cache
+// unless it is already there, verifies it against the release hash and signature and extracts it into the cache dir.
+// When the registry is unreachable the newest matching cached archive is used instead.
+// It returns the release and the SHA-256 of its archive.
+func downloadAndExtractAddon(ctx context.Context, manifest shared.AddonManifest, version string) (api.Release, string, error) {
+	channel := GetAddonChannel(manifest.Name)
+
+	release, err := api.GetAddonRelease(ctx, manifest.Name, version, channel)
 	if err != nil {
-		return err
+		if !api.IsUnreachable(err) {
+			return api.Release{}, "", err
+		}
+		cached, ok := findCachedRelease(manifest.Name, version, channel)
+		if !ok {
+			return api.Release{}, "", err
+		}
+		logger.Warn(manifest.Name + " - Registry unreachable, using cached archive of " + cached.TagName)
+		release = cached
 	}
 
-	AddManagedAddon(manifest, release)
-	return nil
+	archiveHash, err := downloadAndExtractRelease(ctx, manifest, release)
+	if err != nil {
+		return api.Release{}, "", err
+	}
+	return release, archiveHash, nil
 }
 
-func performUpdateFileOperations(manifest shared.AddonManifest) error {
-	cacheExtractDir := filepath.Join(config.GetCacheDir(), manifest.Name)
-
-	// Find the root directory of the extracted release
-	entries, err := os.ReadDir(cacheExtractDir)
+// downloadAndExtractRelease does the work of downloadAndExtractAddon for a release that is already resolved.
+func downloadAndExtractRelease(ctx context.Context, manifest shared.AddonManifest, release api.Release) (string, error) {
+	archiveHash, err := fetchReleaseArchive(ctx, manifest, release)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	var rootDir string
-	for _, e := range entries {
-		if e.IsDir() {
-			rootDir = e.Name()
-			break
+	if file.FileExists(filepath.Join(config.GetCacheDir(), manifest.Name)) {
+		if err := os.RemoveAll(filepath.Join(config.GetCacheDir(), manifest.Name)); err != nil {
+			return "", err
 		}
 	}
-	if rootDir == "" {
-		return errors.New("no root directory found")
+
+	if err := util.ExtractAddonRelease(archiveRelPath(manifest.Name, archiveKey(release)), manifest.Name); err != nil {
+		return "", err
 	}
 
-	destAddonDir := filepath.Join(config.GetAddonDir(), manifest.Name)
+	pruneCachedArchives(manifest.Name, release)
 
-	// Ensure destination exists
-	if err := os.MkdirAll(destAddonDir, os.ModePerm); err != nil {
-		return err
-	}
+	return archiveHash, nil
+}
 
-	// Remove everything in destination except the .data folder
-	destEntries, err := os.ReadDir(destAddonDir)
-	if err != nil {
-		return err
-	}
-	for _, de := range destEntries {
-		name := de.Name()
-		if name == ".data" {
-			continue
+// fetchReleaseArchive downloads the archive of a release into the archive cache unless it is already there,
+// verifies it against the release hash and signature and returns its SHA-256.
+func fetchReleaseArchive(ctx context.Context, manifest shared.AddonManifest, release api.Release) (string, error) {
+	channel := GetAddonChannel(manifest.Name)
+
+	zipPath := archivePath(manifest.Name, archiveKey(release))
+	if !file.FileExists(zipPath) {
+		if err := os.MkdirAll(filepath.Dir(zipPath), os.ModePerm); err != nil {
+			return "", err
+		}
+		onProgress := func(downloaded int64, total int64) {
+			if downloadProgressHandler != nil {
+				downloadProgressHandler(shared.DownloadProgress{Name: manifest.Name, Downloaded: downloaded, Total: total})
+			}
 		}
-		removePath := filepath.Join(destAddonDir, name)
-		if err := os.RemoveAll(removePath); err != nil {
-			return err
+		// A branch head is downloaded by commit so the archive matches the release that was looked up
+		downloadVersion := release.TagName
+		if release.Branch != "" {
+			downloadVersion = release.Tag.Sha
 		}
+		if err := api.DownloadAddon(ctx, manifest.Name, downloadVersion, channel, zipPath, onProgress); err != nil {
+			return "", err
+		}
+		saveCachedRelease(manifest.Name, release)
 	}
 
-	// Copy new release contents into destination, skipping .data
-	srcRoot := filepath.Join(cacheExtractDir, rootDir)
-	srcEntries, err := os.ReadDir(srcRoot)
-	if err != nil {
-		return err
+	archiveHash, err := verifyArchive(zipPath, release)
+	if err == nil {
+		err = verifyArchiveSignature(zipPath, release)
 	}
-	for _, se := range srcEntries {
-		name := se.Name()
-		if name == ".data" {
-			continue
-		}
-		srcPath := filepath.Join(srcRoot, name)
-		destPath := filepath.Join(destAddonDir, name)
-		if se.IsDir() {
-			if err := file.CopyDir(srcPath, destPath); err != nil {
-				return err
-			}
-		} else {
-			if mkErr := os.MkdirAll(filepath.Dir(destPath), os.ModePerm); mkErr != nil {
-				return mkErr
-			}
-			if err := file.MoveFile(srcPath, destPath); err != nil {
-				return err
-			}
-		}
+	if err != nil {
+		// Drop the bad archive so the next attempt downloads it again
+		_ = os.Remove(zipPath)
+		_ = os.Remove(releaseMetaPath(manifest.Name, archiveKey(release)))
+		return "", err
 	}
 
-	// Cleanup extracted cache
-	return os.RemoveAll(cacheExtractDir)
+	return archiveHash, nil
 }
@@ -0,0 +1,401 @@
+package addon
+
+import (
+	"ClassicAddonManager/backend/api"
+	"ClassicAddonManager/backend/logger"
+	"ClassicAddonManager/backend/shared"
+	"ClassicAddonManager/backend/util"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const maxDependencyDepth = 10
+
+// releaseSearchPages bounds how far back in an addon's history a release satisfying a constraint is looked for.
+const releaseSearchPages = 5
+
+// requirement is a parsed DependencyRequirement.
+type requirement struct {
+	by         string
+	constraint util.VersionConstraint
+}
+
+// addRequirement records that by requires name, once per dependent.
+func addRequirement(requirements map[string][]requirement, name string, by string, constraint util.VersionConstraint) {
+	for _, r := range requirements[name] {
+		if r.by == by {
+			return
+		}
+	}
+	requirements[name] = append(requirements[name], requirement{by: by, constraint: constraint})
+}
+
+// installedRequirements returns the constraints the installed addons other than exclude put on name,
+// according to the cached addon manifest.
+func installedRequirements(name string, exclude string) []requirement {
+	installed := make(map[string]struct{})
+	for _, n := range GetAllAddonNames() {
+		installed[n] = struct{}{}
+	}
+
+	requirements := make(map[string][]requirement)
+	for _, manifest := range cachedAddonManifest() {
+		if _, ok := installed[manifest.Name]; !ok || manifest.Name == exclude || manifest.Name == name {
+			continue
+		}
+		for _, entry := range manifest.Dependencies {
+			spec, err := ParseDependency(entry)
+			if err != nil || spec.Name != name {
+				continue
+			}
+			addRequirement(requirements, name, manifest.Name, spec.Constraint)
+		}
+	}
+	return requirements[name]
+}
+
+// constrained drops the requirements that accept every version.
+func constrained(requirements []requirement) []requirement {
+	var result []requirement
+	for _, r := range requirements {
+		if !r.constraint.IsEmpty() {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+func satisfiesAll(version string, requirements []requirement) bool {
+	for _, r := range requirements {
+		if !r.constraint.Check(version) {
+			return false
+		}
+	}
+	return true
+}
+
+// describeRequirements formats requirements as "A needs LibUI <2, B needs LibUI >=2".
+func describeRequirements(name string, requirements []requirement) string {
+	parts := make([]string, 0, len(requirements))
+	for _, r := range requirements {
+		parts = append(parts, fmt.Sprintf("%s needs %s %s", r.by, name, r.constraint))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func newConflict(name string, requirements []requirement) shared.DependencyConflict {
+	conflict := shared.DependencyConflict{
+		Name:         name,
+		Requirements: []shared.DependencyRequirement{},
+	}
+	for _, r := range requirements {
+		conflict.Requirements = append(conflict.Requirements, shared.DependencyRequirement{
+			By:         r.by,
+			Constraint: r.constraint.String(),
+		})
+	}
+
+	conflict.Message = describeRequirements(name, requirements)
+	if len(requirements) == 1 {
+		conflict.Message += ", but no release of " + name + " satisfies it"
+	}
+	return conflict
+}
+
+func combinedConstraint(requirements []requirement) string {
+	parts := make([]string, 0, len(requirements))
+	for _, r := range requirements {
+		parts = append(parts, r.constraint.String())
+	}
+	return strings.Join(parts, " ")
+}
+
+// chooseRelease returns the tag of the newest release of name that satisfies every requirement, looking
+// through the first releaseSearchPages pages of its history. ok is false when none does. When the registry
+// is unreachable the releases in the archive cache are searched instead.
+func chooseRelease(ctx context.Context, name string, requirements []requirement) (tag string, ok bool, err error) {
+	for page := 1; page <= releaseSearchPages; page++ {
+		releases, err := api.GetAddonReleases(ctx, name, page, 100)
+		if err != nil {
+			if !api.IsUnreachable(err) {
+				return "", false, err
+			}
+			tag, ok := newestSatisfying(getCachedReleases(name), requirements)
+			if !ok {
+				return "", false, err
+			}
+			logger.Warn(name + " - Registry unreachable, picked cached release " + tag)
+			return tag, true, nil
+		}
+
+		if tag, ok := newestSatisfying(releases.Releases, requirements); ok {
+			return tag, true, nil
+		}
+
+		if !releases.HasMore {
+			break
+		}
+	}
+	return "", false, nil
+}
+
+// newestSatisfying returns the tag of the first of releases, sorted newest first, that satisfies every
+// requirement.
+func newestSatisfying(releases []api.Release, requirements []requirement) (string, bool) {
+	for _, release := range releases {
+		if satisfiesAll(release.TagName, requirements) {
+			return release.TagName, true
+		}
+	}
+	return "", false
+}
+
+// pickDependencyVersion sets dependency.Version to the newest release satisfying requirements. An installed
+// dependency that already satisfies them is kept as it is. It returns false when no release does.
+func pickDependencyVersion(ctx context.Context, dependency *shared.DependencyInfo, requirements []requirement) (bool, error) {
+	if dependency.IsInstalled {
+		local := FindLocalAddonByName(dependency.Manifest.Name)
+		// The version of an unmanaged addon is unknown, so it is trusted to fit
+		if local == nil || satisfiesAll(local.Version, requirements) {
+			return true, nil
+		}
+	}
+
+	tag, ok, err := chooseRelease(ctx, dependency.Manifest.Name, requirements)
+	if err != nil || !ok {
+		return ok, err
+	}
+
+	dependency.Version = tag
+	return true, nil
+}
+
+// CheckDependentConstraints checks that installing version of name keeps every installed addon's
+// constraint on it satisfied, and returns the conflict when it doesn't.
+func CheckDependentConstraints(name string, version string) (shared.DependencyConflict, bool) {
+	requirements := constrained(installedRequirements(name, ""))
+	if satisfiesAll(version, requirements) {
+		return shared.DependencyConflict{}, true
+	}
+
+	var violated []requirement
+	for _, r := range requirements {
+		if !r.constraint.Check(version) {
+			violated = append(violated, r)
+		}
+	}
+	conflict := newConflict(name, violated)
+	conflict.Message = describeRequirements(name, violated) + ", not " + version
+	return conflict, false
+}
+
+// checkReleaseAgainstDependents refuses a release that breaks the version constraints installed addons put
+// on it. Branch heads have no version to check.
+func checkReleaseAgainstDependents(name string, release api.Release) error {
+	if release.Branch != "" {
+		return nil
+	}
+	if conflict, ok := CheckDependentConstraints(name, release.TagName); !ok {
+		return errors.New("dependency version conflict: " + conflict.Message)
+	}
+	return nil
+}
+
+// ConflictsError joins the messages of dependency conflicts into one error.
+func ConflictsError(conflicts []shared.DependencyConflict) error {
+	messages := make([]string, 0, len(conflicts))
+	for _, conflict := range conflicts {
+		messages = append(messages, conflict.Message)
+	}
+	return errors.New("dependency version conflict: " + strings.Join(messages, "; "))
+}
+
+// ResolveDependencies walks the dependencies of manifest and their dependencies, and picks for each of them
+// a release that satisfies the constraints of manifest, of the other dependencies and of every installed addon.
+func ResolveDependencies(ctx context.Context, manifest shared.AddonManifest) (shared.DependencyResolutionResult, error) {
+	result := shared.DependencyResolutionResult{
+		Dependencies: []shared.DependencyInfo{},
+		Errors:       []string{},
+		Conflicts:    []shared.DependencyConflict{},
+	}
+
+	manifests := GetAddonManifest(ctx)
+	if len(manifests) == 0 {
+		return result, fmt.Errorf("failed to fetch addon manifests")
+	}
+
+	manifestByName := make(map[string]shared.AddonManifest, len(manifests))
+	for _, m := range manifests {
+		manifestByName[m.Name] = m
+	}
+
+	if _, err := ReadAddonsTxt(); err != nil {
+		logger.Error("ResolveDependencies: failed to read addons.txt:", err)
+	}
+
+	installedByName := make(map[string]struct{})
+	for _, name := range GetAllAddonNames() {
+		installedByName[name] = struct{}{}
+	}
+
+	dependencyByName := make(map[string]shared.DependencyInfo)
+	requirements := make(map[string][]requirement)
+
+	var walk func(parent shared.AddonManifest, depth int, lineage map[string]struct{})
+	walk = func(parent shared.AddonManifest, depth int, lineage map[string]struct{}) {
+		for _, entry := range parent.Dependencies {
+			spec, err := ParseDependency(entry)
+			if err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("Invalid dependency %q of %s: %s", entry, parent.Name, err))
+				continue
+			}
+			name := spec.Name
+			addRequirement(requirements, name, parent.Name, spec.Constraint)
+
+			if depth > maxDependencyDepth {
+				result.Errors = append(result.Errors, fmt.Sprintf("Maximum dependency depth exceeded for: %s", name))
+				continue
+			}
+
+			if _, circular := lineage[name]; circular {
+				result.Errors = append(result.Errors, fmt.Sprintf("Circular dependency detected: %s", name))
+				continue
+			}
+
+			dependency, ok := manifestByName[name]
+			if !ok {
+				result.Errors = append(result.Errors, fmt.Sprintf("Addon %s not found in repository manifests", name))
+				continue
+			}
+
+			_, isInstalled := installedByName[name]
+			existing, exists := dependencyByName[name]
+			if !exists || depth > existing.Depth {
+				dependencyByName[name] = shared.DependencyInfo{
+					Manifest:    dependency,
+					IsInstalled: isInstalled,
+					Depth:       depth,
+				}
+			}
+
+			nextLineage := make(map[string]struct{}, len(lineage)+1)
+			for key := range lineage {
+				nextLineage[key] = struct{}{}
+			}
+			nextLineage[name] = struct{}{}
+
+			walk(dependency, depth+1, nextLineage)
+		}
+	}
+
+	walk(manifest, 0, map[string]struct{}{manifest.Name: {}})
+
+	dependencies := make([]shared.DependencyInfo, 0, len(dependencyByName))
+	for name, dependency := range dependencyByName {
+		// The installed addons constrain their dependencies too, except the addon being resolved, whose
+		// new constraints replace its old ones
+		for _, r := range installedRequirements(name, manifest.Name) {
+			addRequirement(requirements, name, r.by, r.constraint)
+		}
+
+		reqs := constrained(requirements[name])
+		dependency.Constraint = combinedConstraint(reqs)
+
+		if len(reqs) > 0 {
+			ok, err := pickDependencyVersion(ctx, &dependency, reqs)
+			if err != nil {
+				return result, err
+			}
+			if !ok {
+				result.Conflicts = append(result.Conflicts, newConflict(name, reqs))
+			}
+		}
+
+		dependencies = append(dependencies, dependency)
+	}
+
+	for _, conflict := range result.Conflicts {
+		result.Errors = append(result.Errors, "Version conflict: "+conflict.Message)
+	}
+
+	sort.SliceStable(dependencies, func(i, j int) bool {
+		if dependencies[i].Depth != dependencies[j].Depth {
+			return dependencies[i].Depth > dependencies[j].Depth
+		}
+		return dependencies[i].Manifest.Name < dependencies[j].Manifest.Name
+	})
+	sort.SliceStable(result.Conflicts, func(i, j int) bool {
+		return result.Conflicts[i].Name < result.Conflicts[j].Name
+	})
+
+	result.Dependencies = dependencies
+	return result, nil
+}
+
+// InstallDependencies installs the resolved dependencies that are missing, and moves installed ones whose
+// version doesn't satisfy the constraints on them to the release picked for them. Local modifications of the
+// dependencies moved are handled according to mode, and checked before anything is installed. It stops at
+// the first failure and returns the status of every dependency handled so far.
+func InstallDependencies(ctx context.Context, dependencies []shared.DependencyInfo, mode string) ([]shared.AddonInstallStatus, error) {
+	statuses := []shared.AddonInstallStatus{}
+
+	for _, dep := range dependencies {
+		if !dep.IsInstalled || dep.Version == "" {
+			continue
+		}
+		if err := CheckLocalModifications(dep.Manifest.Name, mode); err != nil {
+			logger.Error(dep.Manifest.Name+" - Error updating dependency:", err)
+			statuses = append(statuses, shared.AddonInstallStatus{
+				Name:  dep.Manifest.Name,
+				Alias: dep.Manifest.Alias,
+				Error: err.Error(),
+			})
+			return statuses, fmt.Errorf("dependency %s could not be updated: %w", dep.Manifest.Name, err)
+		}
+	}
+
+	for _, dep := range dependencies {
+		status := shared.AddonInstallStatus{
+			Name:  dep.Manifest.Name,
+			Alias: dep.Manifest.Alias,
+		}
+
+		if status.Alias == "" {
+			status.Alias = dep.Manifest.Name
+		}
+
+		if dep.IsInstalled && dep.Version == "" {
+			status.Success = true
+			status.Skipped = true
+			statuses = append(statuses, status)
+			continue
+		}
+
+		version := dep.Version
+		if version == "" {
+			version = "latest"
+		}
+
+		var err error
+		if dep.IsInstalled {
+			// The installed version doesn't satisfy the constraints, move it to one that does
+			_, err = UpdateAddon(ctx, dep.Manifest, version, mode)
+		} else {
+			_, err = InstallAddon(ctx, dep.Manifest, version, InstallReasonDependency)
+		}
+		if err != nil {
+			logger.Error(dep.Manifest.Name+" - Error installing dependency:", err)
+			status.Error = err.Error()
+			statuses = append(statuses, status)
+			return statuses, fmt.Errorf("dependency %s could not be installed: %w", dep.Manifest.Name, err)
+		}
+
+		status.Success = true
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
@@ -0,0 +1,73 @@
+package addon
+
+import (
+	"ClassicAddonManager/backend/api"
+	"ClassicAddonManager/backend/util"
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewConflictMessage(t *testing.T) {
+	below2, _ := util.ParseVersionConstraint("<2")
+	from2, _ := util.ParseVersionConstraint(">= 2")
+
+	conflict := newConflict("LibUI", []requirement{
+		{by: "A", constraint: below2},
+		{by: "B", constraint: from2},
+	})
+	if want := "A needs LibUI <2, B needs LibUI >=2"; conflict.Message != want {
+		t.Errorf("message = %q, want %q", conflict.Message, want)
+	}
+	if len(conflict.Requirements) != 2 || conflict.Requirements[1].By != "B" || conflict.Requirements[1].Constraint != ">=2" {
+		t.Errorf("requirements = %+v", conflict.Requirements)
+	}
+
+	unsatisfiable := newConflict("LibUI", []requirement{{by: "A", constraint: from2}})
+	if want := "A needs LibUI >=2, but no release of LibUI satisfies it"; unsatisfiable.Message != want {
+		t.Errorf("message = %q, want %q", unsatisfiable.Message, want)
+	}
+}
+
+func TestChooseReleaseOffline(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	// The logger writes to app.log in the config dir
+	configDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configDir)
+	if err := os.MkdirAll(filepath.Join(configDir, "ClassicAddonManager"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// A closed server refuses connections, like an unreachable registry
+	server := httptest.NewServer(nil)
+	server.Close()
+	api.SetRegistry(api.NewHTTPRegistry(server.URL))
+	t.Cleanup(func() { api.SetRegistry(nil) })
+
+	published := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, tag := range []string{"1.0.0", "1.4.0", "2.0.0"} {
+		release := api.Release{TagName: tag, PublishedAt: published.AddDate(0, i, 0)}
+		zipPath := archivePath("LibUI", archiveKey(release))
+		if err := os.MkdirAll(filepath.Dir(zipPath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(zipPath, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		saveCachedRelease("LibUI", release)
+	}
+
+	below2, _ := util.ParseVersionConstraint("<2")
+	tag, ok, err := chooseRelease(context.Background(), "LibUI", []requirement{{by: "A", constraint: below2}})
+	if err != nil || !ok || tag != "1.4.0" {
+		t.Errorf("chooseRelease(<2) = %q, %v, %v, want 1.4.0 from the cache", tag, ok, err)
+	}
+
+	from3, _ := util.ParseVersionConstraint(">=3")
+	if _, ok, err := chooseRelease(context.Background(), "LibUI", []requirement{{by: "A", constraint: from3}}); ok || err == nil {
+		t.Errorf("chooseRelease(>=3) = %v, %v, want the registry error", ok, err)
+	}
+}
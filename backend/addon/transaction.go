@@ -0,0 +1,286 @@
+package addon
+
+import (
+	"ClassicAddonManager/backend/config"
+	"ClassicAddonManager/backend/file"
+	"ClassicAddonManager/backend/logger"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+// addonFilesMu serializes transactions, so concurrent installs and updates never interleave their writes
+// to addons.txt and managed_addons.json.
+var addonFilesMu sync.Mutex
+
+// addonTransaction replaces the folder of an addon with a staged tree and keeps enough of the previous
+// state around to restore the folder, its addons.txt entry and its managed_addons.json entry if a later
+// step fails. The staged tree and the previous folder live next to the addon folder so both swaps are
+// plain renames on the same volume.
+type addonTransaction struct {
+	name       string
+	addonDir   string
+	stagingDir string
+	backupDir  string
+
+	hadFolder       bool
+	swapped         bool
+	wasInAddonsTxt  bool
+	previousManaged *Addon
+
+	// carryOver are locally modified files copied over the staged release, and carriedHashes the hashes
+	// the release ships for them
+	carryOver     []string
+	carriedHashes map[string]string
+	// overwritten are preserved paths the release shipped, replaced by the installed copy
+	overwritten []string
+	// dataFrom is the folder of a replaced addon whose .data folder is staged in place of the release's
+	dataFrom string
+
+	// replaced are installed addons the release takes the place of. Their list entries move to the new
+	// addon, their folders are moved aside until commit and their managed_addons.json entries are dropped.
+	replaced        []string
+	replacedAside   []string
+	replacedManaged map[string]Addon
+	addonsTxtBefore []string
+	disabledBefore  []string
+}
+
+// previousAddonDir is where a transaction keeps the folder of an addon it replaces until it commits.
+func previousAddonDir(name string) string {
+	return filepath.Join(config.GetAddonDir(), "."+name+".previous")
+}
+
+func beginAddonTransaction(name string) (*addonTransaction, error) {
+	t := &addonTransaction{
+		name:            name,
+		addonDir:        filepath.Join(config.GetAddonDir(), name),
+		stagingDir:      filepath.Join(config.GetAddonDir(), "."+name+".staging"),
+		backupDir:       previousAddonDir(name),
+		wasInAddonsTxt:  slices.Contains(GetInstalledAddonNames(), name),
+		previousManaged: FindLocalAddonByName(name),
+	}
+
+	if err := t.recover(); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// recover cleans up after a transaction that was interrupted, putting the previous folder back if it
+// was moved aside but never replaced.
+func (t *addonTransaction) recover() error {
+	if file.FileExists(t.backupDir) {
+		if !file.FileExists(t.addonDir) {
+			logger.Warn(t.name + " - Restoring folder left behind by an interrupted install")
+			if err := os.Rename(t.backupDir, t.addonDir); err != nil {
+				return fmt.Errorf("could not restore previous %s folder: %w", t.name, err)
+			}
+		} else if err := os.RemoveAll(t.backupDir); err != nil {
+			return err
+		}
+	}
+
+	return os.RemoveAll(t.stagingDir)
+}
+
+// stage moves the release extracted into the cache dir into the staging folder. When keepData is set,
+// the .data folder of the installed addon and every path its preserve rules cover are copied over in
+// place of what the release ships.
+func (t *addonTransaction) stage(keepData bool) error {
+	cacheExtractDir := filepath.Join(config.GetCacheDir(), t.name)
+
+	rootDir, err := findReleaseRoot(cacheExtractDir)
+	if err != nil {
+		return err
+	}
+
+	if err := file.MoveDir(filepath.Join(cacheExtractDir, rootDir), t.stagingDir); err != nil {
+		return err
+	}
+	_ = os.RemoveAll(cacheExtractDir)
+
+	if !keepData {
+		return t.stageReplacedData()
+	}
+
+	if err := os.RemoveAll(filepath.Join(t.stagingDir, ".data")); err != nil {
+		return err
+	}
+
+	oldData := filepath.Join(t.addonDir, ".data")
+	if file.FileExists(oldData) {
+		if err := file.CopyDir(oldData, filepath.Join(t.stagingDir, ".data")); err != nil {
+			return fmt.Errorf("could not carry over .data folder: %w", err)
+		}
+	}
+
+	// Rules shipped with the new release win over the installed ones, so a release can add paths to keep
+	rules := GetPreserveRules(t.name, t.stagingDir)
+	if rules == nil {
+		rules = GetPreserveRules(t.name, t.addonDir)
+	}
+
+	t.overwritten, err = carryOverPreserved(t.name, t.addonDir, t.stagingDir, rules)
+	if err != nil {
+		return err
+	}
+
+	t.carriedHashes, err = carryOverModified(t, t.carryOver)
+	return err
+}
+
+// stageReplacedData copies the .data folder of the addon being replaced into the staged release, so a
+// failed copy leaves both addons as they were.
+func (t *addonTransaction) stageReplacedData() error {
+	if t.dataFrom == "" {
+		return nil
+	}
+
+	stagedData := filepath.Join(t.stagingDir, ".data")
+	if err := os.RemoveAll(stagedData); err != nil {
+		return err
+	}
+	if err := file.CopyDir(filepath.Join(t.dataFrom, ".data"), stagedData); err != nil {
+		return fmt.Errorf("could not migrate .data folder of %s: %w", filepath.Base(t.dataFrom), err)
+	}
+	return nil
+}
+
+// swap moves the current addon folder aside and the staged tree into its place.
+func (t *addonTransaction) swap() error {
+	if file.FileExists(t.addonDir) {
+		if err := os.Rename(t.addonDir, t.backupDir); err != nil {
+			return fmt.Errorf("could not move current %s folder aside, is the game running? %w", t.name, err)
+		}
+		t.hadFolder = true
+	}
+
+	if err := os.Rename(t.stagingDir, t.addonDir); err != nil {
+		return fmt.Errorf("could not move new %s folder into place: %w", t.name, err)
+	}
+	t.swapped = true
+
+	return nil
+}
+
+// migrateReplaced moves the addons.txt entries of the replaced addons to the new one, moves their folders
+// aside and drops their managed_addons.json entries. Their .data folder was already staged.
+func (t *addonTransaction) migrateReplaced() error {
+	if len(t.replaced) == 0 {
+		return nil
+	}
+
+	t.addonsTxtBefore = slices.Clone(GetInstalledAddonNames())
+	t.disabledBefore = GetDisabledAddonNames()
+	t.replacedManaged = make(map[string]Addon)
+
+	for _, oldName := range t.replaced {
+		logger.Info("Migrating " + oldName + " to its replacement " + t.name)
+
+		if err := renameInAddonLists(oldName, t.name); err != nil {
+			return err
+		}
+
+		oldDir := filepath.Join(config.GetAddonDir(), oldName)
+		if file.FileExists(oldDir) {
+			if err := os.RemoveAll(previousAddonDir(oldName)); err != nil {
+				return err
+			}
+			if err := os.Rename(oldDir, previousAddonDir(oldName)); err != nil {
+				return fmt.Errorf("could not move replaced %s folder aside, is the game running? %w", oldName, err)
+			}
+			t.replacedAside = append(t.replacedAside, oldName)
+		}
+
+		if previous, ok := LocalAddons[oldName]; ok {
+			t.replacedManaged[oldName] = previous
+			delete(LocalAddons, oldName)
+		}
+	}
+
+	return SaveManagedAddonsToDisk()
+}
+
+// commit discards the previous folder and the folders of the replaced addons.
+func (t *addonTransaction) commit() {
+	if err := os.RemoveAll(t.backupDir); err != nil {
+		logger.Error(t.name+" - Error removing previous addon folder:", err)
+	}
+	for _, oldName := range t.replacedAside {
+		if err := os.RemoveAll(previousAddonDir(oldName)); err != nil {
+			logger.Error(oldName+" - Error removing replaced addon directory:", err)
+		}
+	}
+}
+
+// rollback restores the addon folder, addons.txt and managed_addons.json to how they were when the
+// transaction began, along with the addons it replaced.
+func (t *addonTransaction) rollback() {
+	logger.Warn(t.name + " - Rolling back install")
+
+	if t.swapped {
+		if err := os.RemoveAll(t.addonDir); err != nil {
+			logger.Error(t.name+" - Error removing new addon folder during rollback:", err)
+		}
+	}
+	if t.hadFolder {
+		if err := os.Rename(t.backupDir, t.addonDir); err != nil {
+			logger.Error(t.name+" - Error restoring previous addon folder, it was kept at "+t.backupDir+":", err)
+		}
+	}
+	if err := os.RemoveAll(t.stagingDir); err != nil {
+		logger.Error(t.name+" - Error removing staged addon folder:", err)
+	}
+	for _, oldName := range t.replacedAside {
+		if err := os.Rename(previousAddonDir(oldName), filepath.Join(config.GetAddonDir(), oldName)); err != nil {
+			logger.Error(oldName+" - Error restoring replaced addon folder, it was kept at "+previousAddonDir(oldName)+":", err)
+		}
+	}
+
+	if t.addonsTxtBefore != nil {
+		if err := restoreAddonsTxt(t.addonsTxtBefore); err != nil {
+			logger.Error(t.name+" - Error restoring addons.txt during rollback:", err)
+		}
+		if err := restoreDisabledAddonNames(t.disabledBefore); err != nil {
+			logger.Error(t.name+" - Error restoring disabled_addons.txt during rollback:", err)
+		}
+	}
+	if !t.wasInAddonsTxt {
+		if err := RemoveFromAddonsTxt(t.name); err != nil {
+			logger.Error(t.name+" - Error restoring addons.txt during rollback:", err)
+		}
+	}
+
+	for oldName, previous := range t.replacedManaged {
+		LocalAddons[oldName] = previous
+	}
+	if t.previousManaged != nil {
+		LocalAddons[t.name] = *t.previousManaged
+	} else {
+		delete(LocalAddons, t.name)
+	}
+	if err := SaveManagedAddonsToDisk(); err != nil {
+		logger.Error(t.name+" - Error restoring managed_addons.json during rollback:", err)
+	}
+}
+
+// findReleaseRoot returns the name of the top level folder of a release extracted to dir.
+func findReleaseRoot(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for _, e := range entries {
+		if e.IsDir() {
+			return e.Name(), nil
+		}
+	}
+
+	return "", errors.New("no root directory found")
+}
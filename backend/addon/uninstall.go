@@ -0,0 +1,142 @@
+package addon
+
+import (
+	"ClassicAddonManager/backend/config"
+	"ClassicAddonManager/backend/logger"
+	"ClassicAddonManager/backend/shared"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+)
+
+// Uninstall modes, deciding what happens to an addon that other installed addons depend on.
+const (
+	// UninstallRefuse refuses to uninstall an addon with installed dependents. It is the default.
+	UninstallRefuse = ""
+	// UninstallCascade uninstalls the addon together with everything that depends on it.
+	UninstallCascade = "cascade"
+	// UninstallUnmanage keeps the addon installed for its dependents and only stops managing it.
+	UninstallUnmanage = "unmanage"
+)
+
+// FindDependents returns the installed addons whose manifest lists name as a dependency, according to the
+// cached addon manifest.
+func FindDependents(name string) []string {
+	installed := GetAllAddonNames()
+
+	var dependents []string
+	for _, manifest := range cachedAddonManifest() {
+		if manifest.Name == name || !slices.Contains(installed, manifest.Name) {
+			continue
+		}
+		if slices.Contains(DependencyNames(manifest), name) {
+			dependents = append(dependents, manifest.Name)
+		}
+	}
+
+	sort.Strings(dependents)
+	return dependents
+}
+
+// findDependentsRecursive returns every installed addon that depends on name directly or through other addons.
+func findDependentsRecursive(name string) []string {
+	seen := map[string]struct{}{name: {}}
+	queue := []string{name}
+
+	var dependents []string
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, dependent := range FindDependents(current) {
+			if _, ok := seen[dependent]; ok {
+				continue
+			}
+			seen[dependent] = struct{}{}
+			dependents = append(dependents, dependent)
+			queue = append(queue, dependent)
+		}
+	}
+
+	sort.Strings(dependents)
+	return dependents
+}
+
+// UninstallAddon removes an addon's folder, its addons.txt entry and its managed_addons.json entry. An addon
+// that other installed addons depend on is handled according to mode.
+func UninstallAddon(name string, mode string) shared.UninstallResult {
+	result := shared.UninstallResult{
+		Dependents: []string{},
+		Removed:    []string{},
+		Unmanaged:  []string{},
+	}
+
+	if !IsInstalled(name) {
+		result.Error = name + " is not installed"
+		return result
+	}
+
+	dependents := FindDependents(name)
+	result.Dependents = append(result.Dependents, dependents...)
+
+	if len(dependents) > 0 {
+		switch mode {
+		case UninstallRefuse:
+			result.Error = fmt.Sprintf("%s is required by %d installed addon(s)", name, len(dependents))
+			return result
+		case UninstallUnmanage:
+			logger.Info(name + " - Kept installed for its dependents, unmanaging it instead")
+			RemoveManagedAddon(name)
+			result.Unmanaged = append(result.Unmanaged, name)
+			result.Success = true
+			return result
+		case UninstallCascade:
+			for _, dependent := range findDependentsRecursive(name) {
+				if err := removeAddon(dependent); err != nil {
+					result.Error = err.Error()
+					return result
+				}
+				result.Removed = append(result.Removed, dependent)
+			}
+		default:
+			result.Error = "unknown uninstall mode " + mode
+			return result
+		}
+	}
+
+	if err := removeAddon(name); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Removed = append(result.Removed, name)
+	result.Success = true
+
+	return result
+}
+
+func removeAddon(name string) error {
+	addonFilesMu.Lock()
+	defer addonFilesMu.Unlock()
+
+	logger.Info("Uninstalling addon: " + name)
+
+	if err := os.RemoveAll(filepath.Join(config.GetAddonDir(), name)); err != nil {
+		logger.Error("Error removing addon directory:", err)
+		return err
+	}
+
+	RemoveManagedAddon(name)
+
+	if err := RemoveFromAddonsTxt(name); err != nil {
+		logger.Error("Error removing addon from addons.txt:", err)
+		return err
+	}
+
+	if err := setDisabled(name, false); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,301 @@
+package addon
+
+import (
+	"ClassicAddonManager/backend/api"
+	"ClassicAddonManager/backend/config"
+	"ClassicAddonManager/backend/logger"
+	"ClassicAddonManager/backend/shared"
+	"context"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultUpdateWorkers is how many archives UpdateAll downloads at once unless general.updateworkers is set.
+const DefaultUpdateWorkers = 4
+
+const maxUpdateWorkers = 16
+
+// GetUpdateWorkers returns how many archives UpdateAll downloads at once.
+func GetUpdateWorkers() int {
+	workers := config.GetInt("general.updateworkers")
+	if workers <= 0 {
+		return DefaultUpdateWorkers
+	}
+	return min(workers, maxUpdateWorkers)
+}
+
+// pendingUpdate is a managed addon with a newer release that UpdateAll is going to install.
+type pendingUpdate struct {
+	manifest    shared.AddonManifest
+	release     api.Release
+	archiveHash string
+	err         error
+	// index is the position of the update's entry in the report
+	index int
+}
+
+// UpdateAll updates every managed addon that has a newer release on its channel. The latest releases are
+// looked up in one request, archives are downloaded by up to GetUpdateWorkers workers and the downloaded
+// releases are then installed one at a time. Local modifications are handled according to mode, and
+// addons whose update they abort are reported as skipped.
+func UpdateAll(ctx context.Context, mode string) (shared.UpdateAllReport, error) {
+	report := shared.UpdateAllReport{Results: []shared.AddonUpdateResult{}}
+
+	ensureAddonsTxtExists()
+
+	managed := make([]Addon, 0, len(LocalAddons))
+	for _, a := range LocalAddons {
+		if a.IsManaged {
+			managed = append(managed, a)
+		}
+	}
+	sort.Slice(managed, func(i, j int) bool {
+		return managed[i].Name < managed[j].Name
+	})
+
+	names := make([]string, 0, len(managed))
+	channels := make(map[string]api.Channel, len(managed))
+	for _, a := range managed {
+		if a.Pinned {
+			continue
+		}
+		names = append(names, a.Name)
+		channels[a.Name] = a.Channel
+	}
+
+	var releases map[string]api.Release
+	if len(names) > 0 {
+		var err error
+		releases, err = api.GetLatestReleasesBulk(ctx, names, channels)
+		if err != nil {
+			logger.Error("UpdateAll: Error getting latest releases:", err)
+			return report, err
+		}
+	}
+
+	manifestByName := make(map[string]shared.AddonManifest)
+	for _, manifest := range GetAddonManifest(ctx) {
+		manifestByName[manifest.Name] = manifest
+	}
+
+	var pending []*pendingUpdate
+	for _, a := range managed {
+		result := shared.AddonUpdateResult{
+			Name:             a.Name,
+			Alias:            a.Alias,
+			FromVersion:      a.Version,
+			Dependencies:     []shared.AddonInstallStatus{},
+			OverwrittenPaths: []string{},
+		}
+
+		if a.Pinned {
+			result.Status = shared.UpdateStatusSkipped
+			result.Reason = "pinned"
+			report.Results = append(report.Results, result)
+			continue
+		}
+
+		release, ok := releases[a.Name]
+		if !ok {
+			continue
+		}
+
+		version := a.releaseVersion(release)
+		if version == "" || version == a.installedVersion() {
+			continue
+		}
+
+		result.ToVersion = release.TagName
+		if version == a.SkippedVersion {
+			result.Status = shared.UpdateStatusSkipped
+			result.Reason = "version skipped"
+			report.Results = append(report.Results, result)
+			continue
+		}
+
+		if err := checkReleaseAgainstDependents(a.Name, release); err != nil {
+			result.Status = shared.UpdateStatusSkipped
+			result.Reason = err.Error()
+			report.Results = append(report.Results, result)
+			continue
+		}
+
+		manifest, ok := manifestByName[a.Name]
+		if !ok {
+			manifest = manifestFromAddon(a)
+		}
+
+		pending = append(pending, &pendingUpdate{
+			manifest: manifest,
+			release:  release,
+			index:    len(report.Results),
+		})
+		report.Results = append(report.Results, result)
+	}
+
+	if len(pending) == 0 {
+		return report, nil
+	}
+
+	downloadPendingUpdates(ctx, pending)
+
+	// Dependencies are updated before their dependents, and ones still waiting for their turn are left to
+	// their own entry so that the release staged for them is only installed once
+	waiting := make(map[string]bool, len(pending))
+	for _, p := range pending {
+		waiting[p.manifest.Name] = true
+	}
+
+	for _, p := range orderPendingUpdates(pending) {
+		delete(waiting, p.manifest.Name)
+		result := &report.Results[p.index]
+
+		if p.err != nil {
+			result.Status = shared.UpdateStatusFailed
+			result.Error = p.err.Error()
+			continue
+		}
+
+		carryOver, err := handleLocalModifications(p.manifest.Name, mode)
+		if err != nil {
+			result.Status = shared.UpdateStatusSkipped
+			result.Reason = err.Error()
+			continue
+		}
+
+		if err := installUpdateDependencies(ctx, p.manifest, mode, waiting, result); err != nil {
+			logger.Error(p.manifest.Name+" - Error installing dependencies, skipping update:", err)
+			result.Status = shared.UpdateStatusFailed
+			result.Error = err.Error()
+			continue
+		}
+
+		if err := createAddonBackup(p.manifest.Name); err != nil {
+			logger.Error(p.manifest.Name+" - Error backing up addon, skipping update:", err)
+			result.Status = shared.UpdateStatusFailed
+			result.Error = err.Error()
+			continue
+		}
+
+		overwritten, err := applyRelease(p.manifest, p.release, p.archiveHash, true, "", carryOver, "", nil)
+		if err != nil {
+			result.Status = shared.UpdateStatusRolledBack
+			result.Error = err.Error()
+			continue
+		}
+		if overwritten != nil {
+			result.OverwrittenPaths = overwritten
+		}
+
+		result.Status = shared.UpdateStatusUpdated
+		logger.Info(p.manifest.Name + " updated successfully")
+	}
+
+	return report, nil
+}
+
+// installUpdateDependencies resolves the dependencies of an addon about to be updated and installs the ones
+// it is missing, recording them on result. mode applies to the dependencies that are updated. Installed
+// dependencies in waiting are left alone, as UpdateAll updates them later.
+func installUpdateDependencies(ctx context.Context, manifest shared.AddonManifest, mode string, waiting map[string]bool, result *shared.AddonUpdateResult) error {
+	if len(manifest.Dependencies) == 0 {
+		return nil
+	}
+
+	resolution, err := ResolveDependencies(ctx, manifest)
+	if err != nil {
+		return err
+	}
+	if len(resolution.Conflicts) > 0 {
+		return ConflictsError(resolution.Conflicts)
+	}
+
+	toInstall := make([]shared.DependencyInfo, 0, len(resolution.Dependencies))
+	for _, dependency := range resolution.Dependencies {
+		if !dependency.IsInstalled || !waiting[dependency.Manifest.Name] {
+			toInstall = append(toInstall, dependency)
+		}
+	}
+
+	dependencies, err := InstallDependencies(ctx, toInstall, mode)
+	for _, dependency := range dependencies {
+		if !dependency.Skipped {
+			result.Dependencies = append(result.Dependencies, dependency)
+		}
+	}
+	return err
+}
+
+// orderPendingUpdates orders pending updates so that every addon comes after the pending addons it depends
+// on, keeping their order otherwise. Addons that depend on each other stay in the order they were in.
+func orderPendingUpdates(pending []*pendingUpdate) []*pendingUpdate {
+	byName := make(map[string]*pendingUpdate, len(pending))
+	for _, p := range pending {
+		byName[p.manifest.Name] = p
+	}
+
+	ordered := make([]*pendingUpdate, 0, len(pending))
+	visited := make(map[string]bool, len(pending))
+	var visit func(p *pendingUpdate)
+	visit = func(p *pendingUpdate) {
+		if visited[p.manifest.Name] {
+			return
+		}
+		visited[p.manifest.Name] = true
+		for _, name := range DependencyNames(p.manifest) {
+			if dependency, ok := byName[name]; ok {
+				visit(dependency)
+			}
+		}
+		ordered = append(ordered, p)
+	}
+
+	for _, p := range pending {
+		visit(p)
+	}
+	return ordered
+}
+
+// downloadPendingUpdates downloads, verifies and extracts the release of every pending update, using at
+// most GetUpdateWorkers workers at once. Failures are recorded on the update.
+func downloadPendingUpdates(ctx context.Context, pending []*pendingUpdate) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, GetUpdateWorkers())
+
+	for _, p := range pending {
+		wg.Add(1)
+		go func(p *pendingUpdate) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			if err := ctx.Err(); err != nil {
+				p.err = err
+				return
+			}
+
+			logger.Info("Updating addon:" + p.manifest.Name + " to version: " + p.release.TagName)
+			p.archiveHash, p.err = downloadAndExtractRelease(ctx, p.manifest, p.release)
+		}(p)
+	}
+
+	wg.Wait()
+}
+
+// manifestFromAddon rebuilds the manifest of a managed addon that is no longer listed by the registry.
+func manifestFromAddon(a Addon) shared.AddonManifest {
+	manifest := shared.AddonManifest{
+		Name:        a.Name,
+		Alias:       a.Alias,
+		Description: a.Description,
+		Author:      a.Author,
+		Repo:        a.Repo,
+		Branch:      a.Branch,
+	}
+	if manifest.Alias == strings.ReplaceAll(a.Name, "_", " ") {
+		manifest.Alias = ""
+	}
+	return manifest
+}
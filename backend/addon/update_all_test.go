@@ -0,0 +1,52 @@
+package addon
+
+import (
+	"ClassicAddonManager/backend/shared"
+	"slices"
+	"testing"
+)
+
+func TestOrderPendingUpdates(t *testing.T) {
+	pending := func(name string, dependencies ...string) *pendingUpdate {
+		return &pendingUpdate{manifest: shared.AddonManifest{Name: name, Dependencies: dependencies}}
+	}
+	names := func(updates []*pendingUpdate) []string {
+		out := make([]string, 0, len(updates))
+		for _, p := range updates {
+			out = append(out, p.manifest.Name)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name    string
+		pending []*pendingUpdate
+		want    []string
+	}{
+		{
+			name:    "independent",
+			pending: []*pendingUpdate{pending("A"), pending("B", "LibNotPending")},
+			want:    []string{"A", "B"},
+		},
+		{
+			name:    "dependency sorts after dependent",
+			pending: []*pendingUpdate{pending("Addon", "LibUI >=2"), pending("LibUI")},
+			want:    []string{"LibUI", "Addon"},
+		},
+		{
+			name:    "chain",
+			pending: []*pendingUpdate{pending("A", "B"), pending("B", "C ^1"), pending("C")},
+			want:    []string{"C", "B", "A"},
+		},
+		{
+			name:    "depend on each other",
+			pending: []*pendingUpdate{pending("A", "B"), pending("B", "A")},
+			want:    []string{"B", "A"},
+		},
+	}
+	for _, tt := range tests {
+		if got := names(orderPendingUpdates(tt.pending)); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: orderPendingUpdates() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
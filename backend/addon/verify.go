@@ -0,0 +1,132 @@
+package addon
+
+import (
+	"ClassicAddonManager/backend/api"
+	"ClassicAddonManager/backend/config"
+	"ClassicAddonManager/backend/logger"
+	"ClassicAddonManager/backend/util"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type VerificationResult struct {
+	Name string `json:"name"`
+	// Recorded is false when no file hashes were recorded at install time, so nothing could be compared.
+	Recorded bool     `json:"recorded"`
+	Intact   bool     `json:"intact"`
+	Modified []string `json:"modified"`
+	Missing  []string `json:"missing"`
+	Added    []string `json:"added"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// hashInstalledFiles hashes every file of an installed addon except its .data folder and the other paths
+// its preserve rules cover, which hold user data that is expected to change.
+func hashInstalledFiles(name string) (map[string]string, error) {
+	rules := installedPreserveRules(name)
+	return util.HashDir(filepath.Join(config.GetAddonDir(), name), func(rel string) bool {
+		return matchesPreserveRule(rel, rules)
+	})
+}
+
+// verifyArchive checks the archive at path against the hash published with its release and returns the archive's hash.
+// Releases without a published hash are accepted.
+func verifyArchive(path string, release api.Release) (string, error) {
+	hash, err := util.HashFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	if release.Sha256 == "" {
+		logger.Warn("No hash published for " + filepath.Base(path) + ", skipping integrity check")
+		return hash, nil
+	}
+
+	if !strings.EqualFold(hash, release.Sha256) {
+		return "", fmt.Errorf("archive integrity check failed for %s %s: expected sha256 %s, got %s",
+			filepath.Base(filepath.Dir(path)), release.TagName, release.Sha256, hash)
+	}
+
+	return hash, nil
+}
+
+// verifyArchiveSignature checks the archive at path against the signature published with its release.
+func verifyArchiveSignature(path string, release api.Release) error {
+	verified, err := util.NewSignatureVerifier().VerifyFile(path, release.Signature)
+	if err != nil {
+		return fmt.Errorf("signature check failed for %s %s: %w", filepath.Base(filepath.Dir(path)), release.TagName, err)
+	}
+	if !verified {
+		logger.Warn("No trusted signature for " + filepath.Base(filepath.Dir(path)) + " " + release.TagName + ", installing unverified")
+	}
+	return nil
+}
+
+// VerifyInstalledAddon re-hashes the files of a managed addon and compares them to what was recorded at install time.
+func VerifyInstalledAddon(name string) VerificationResult {
+	result := VerificationResult{
+		Name:     name,
+		Modified: []string{},
+		Missing:  []string{},
+		Added:    []string{},
+	}
+
+	local := FindLocalAddonByName(name)
+	if local == nil {
+		result.Error = name + " is not a managed addon"
+		return result
+	}
+	if len(local.Files) == 0 {
+		return result
+	}
+	result.Recorded = true
+
+	if _, err := os.Stat(filepath.Join(config.GetAddonDir(), name)); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	current, err := hashInstalledFiles(name)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	for rel, hash := range local.Files {
+		currentHash, ok := current[rel]
+		if !ok {
+			result.Missing = append(result.Missing, rel)
+		} else if currentHash != hash {
+			result.Modified = append(result.Modified, rel)
+		}
+	}
+	for rel := range current {
+		if _, ok := local.Files[rel]; !ok {
+			result.Added = append(result.Added, rel)
+		}
+	}
+
+	sort.Strings(result.Modified)
+	sort.Strings(result.Missing)
+	sort.Strings(result.Added)
+	result.Intact = len(result.Modified) == 0 && len(result.Missing) == 0 && len(result.Added) == 0
+	return result
+}
+
+// VerifyInstalledAddons verifies every managed addon, sorted by name.
+func VerifyInstalledAddons() []VerificationResult {
+	names := make([]string, 0, len(LocalAddons))
+	for name := range LocalAddons {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	results := make([]VerificationResult, 0, len(names))
+	for _, name := range names {
+		results = append(results, VerifyInstalledAddon(name))
+	}
+	return results
+}
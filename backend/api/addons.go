@@ -2,50 +2,77 @@ package api
 
 import (
 	"ClassicAddonManager/backend/auth"
+	"ClassicAddonManager/backend/httpclient"
 	"ClassicAddonManager/backend/logger"
 	"ClassicAddonManager/backend/shared"
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"net/http"
-	"strconv"
 )
 
-func UnsubscribeFromAddon(addonName string) {
-	url := fmt.Sprintf("%s/addon/%s/unsubscribe", ApiURL, addonName)
+func (r *HTTPRegistry) SubscribeToAddon(ctx context.Context, addonName string) error {
+	endpoint := fmt.Sprintf("/addon/%s/subscribe", addonName)
 
-	req, err := http.NewRequest("POST", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "POST", r.baseURL+endpoint, nil)
 	if err != nil {
 		logger.Error("Error creating request:", err)
-		return
+		return err
 	}
 
 	req.Header.Set("X-Client", GetApiClientHeader())
 	req.Header.Set("X-Token", auth.GetToken())
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := httpclient.Do(req)
+	if err != nil {
+		logger.Error("Error sending request:", err)
+		return err
+	}
+	defer resp.Body.Close()
+
+	if _, err := readResponse(endpoint, resp); err != nil {
+		logger.Error("Error subscribing to addon:", err)
+		return err
+	}
+
+	logger.Info("Subscribed to addon: " + addonName)
+	return nil
+}
+
+func (r *HTTPRegistry) UnsubscribeFromAddon(ctx context.Context, addonName string) error {
+	endpoint := fmt.Sprintf("/addon/%s/unsubscribe", addonName)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", r.baseURL+endpoint, nil)
 	if err != nil {
 		logger.Error("Error creating request:", err)
-		return
+		return err
+	}
+
+	req.Header.Set("X-Client", GetApiClientHeader())
+	req.Header.Set("X-Token", auth.GetToken())
+	resp, err := httpclient.Do(req)
+	if err != nil {
+		logger.Error("Error creating request:", err)
+		return err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		logger.Error("Error unsubscribing from addon:", errors.New(strconv.Itoa(resp.StatusCode)))
-		return
+	if _, err := readResponse(endpoint, resp); err != nil {
+		logger.Error("Error unsubscribing from addon:", err)
+		return err
 	}
 
 	logger.Info("Unsubscribed from addon: " + addonName)
+	return nil
 }
 
 type SubscribedAddonsResponse struct {
 	Addons []shared.AddonManifest `json:"data"`
 }
 
-func GetSubscribedAddons() ([]shared.AddonManifest, error) {
-	url := fmt.Sprintf("%s/me/addons", ApiURL)
+func (r *HTTPRegistry) GetSubscribedAddons(ctx context.Context) ([]shared.AddonManifest, error) {
+	endpoint := "/me/addons"
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", r.baseURL+endpoint, nil)
 	if err != nil {
 		logger.Error("Error creating request:", err)
 		return nil, err
@@ -53,22 +80,21 @@ func GetSubscribedAddons() ([]shared.AddonManifest, error) {
 
 	req.Header.Set("X-Client", GetApiClientHeader())
 	req.Header.Set("X-Token", auth.GetToken())
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := httpclient.Do(req)
 	if err != nil {
 		logger.Error("Error sending request:", err)
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		logger.Error("Error getting subscribed addons:", errors.New(strconv.Itoa(resp.StatusCode)))
-		return nil, errors.New(strconv.Itoa(resp.StatusCode))
+	body, err := readResponse(endpoint, resp)
+	if err != nil {
+		logger.Error("Error getting subscribed addons:", err)
+		return nil, err
 	}
 
 	var response SubscribedAddonsResponse
-	err = json.NewDecoder(resp.Body).Decode(&response)
-	if err != nil {
+	if err := json.Unmarshal(body, &response); err != nil {
 		logger.Error("Error decoding response:", err)
 		return nil, err
 	}
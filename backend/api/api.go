@@ -2,8 +2,11 @@ package api
 
 import "ClassicAddonManager/backend/shared"
 
-const ApiURL = "https://aac.gaijin.dev"
-
 func GetApiClientHeader() string {
 	return "Classic Addon Manager " + shared.Version
 }
+
+// BaseURL returns the base URL of the active registry.
+func BaseURL() string {
+	return GetRegistry().BaseURL()
+}
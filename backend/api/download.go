@@ -0,0 +1,27 @@
+package api
+
+import (
+	"ClassicAddonManager/backend/util"
+	"context"
+	"fmt"
+	"net/url"
+)
+
+func addonDownloadPath(name string, version string, channel Channel) string {
+	query := url.Values{}
+	if version != "" && version != "latest" {
+		query.Set("version", version)
+	}
+	channel.setQuery(query)
+
+	if len(query) == 0 {
+		return fmt.Sprintf("/addon/%s/download", name)
+	}
+	return fmt.Sprintf("/addon/%s/download?%s", name, query.Encode())
+}
+
+func (r *HTTPRegistry) DownloadAddon(ctx context.Context, name string, version string, channel Channel, dest string, onProgress util.ProgressFunc) error {
+	return util.DownloadFile(ctx, r.baseURL+addonDownloadPath(name, version, channel), dest, util.DownloadOptions{
+		OnProgress: onProgress,
+	})
+}
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+type ErrorKind string
+
+const (
+	ErrorKindNotFound     ErrorKind = "not_found"
+	ErrorKindUnauthorized ErrorKind = "unauthorized"
+	ErrorKindRateLimited  ErrorKind = "rate_limited"
+	ErrorKindServerError  ErrorKind = "server_error"
+	// ErrorKindRejected covers any other non-2xx status and responses where the API reports status false.
+	ErrorKindRejected ErrorKind = "rejected"
+)
+
+// Error is returned when the registry answers a request with an error status or reports a failure
+// in the response body. Its fields are serialized to the frontend as the cause of a failed call.
+type Error struct {
+	Endpoint string    `json:"endpoint"`
+	Status   int       `json:"status"`
+	Message  string    `json:"message"`
+	Kind     ErrorKind `json:"kind"`
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("%s: status %d", e.Endpoint, e.Status)
+	}
+	return fmt.Sprintf("%s: status %d: %s", e.Endpoint, e.Status, e.Message)
+}
+
+func newError(endpoint string, status int, message string) *Error {
+	return &Error{
+		Endpoint: endpoint,
+		Status:   status,
+		Message:  message,
+		Kind:     classifyStatus(status),
+	}
+}
+
+func classifyStatus(status int) ErrorKind {
+	switch {
+	case status == http.StatusNotFound:
+		return ErrorKindNotFound
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return ErrorKindUnauthorized
+	case status == http.StatusTooManyRequests:
+		return ErrorKindRateLimited
+	case status >= 500:
+		return ErrorKindServerError
+	default:
+		return ErrorKindRejected
+	}
+}
+
+// ErrorKindOf returns the kind of an *Error in err's chain, or "" if err did not come from the registry.
+func ErrorKindOf(err error) ErrorKind {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Kind
+	}
+	return ""
+}
+
+func IsNotFound(err error) bool {
+	return ErrorKindOf(err) == ErrorKindNotFound
+}
+
+func IsUnauthorized(err error) bool {
+	return ErrorKindOf(err) == ErrorKindUnauthorized
+}
+
+func IsRateLimited(err error) bool {
+	return ErrorKindOf(err) == ErrorKindRateLimited
+}
+
+func IsServerError(err error) bool {
+	return ErrorKindOf(err) == ErrorKindServerError
+}
+
+// IsUnreachable reports whether err means the registry could not be reached or failed on its end,
+// as opposed to answering that the request itself was invalid.
+func IsUnreachable(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	kind := ErrorKindOf(err)
+	return kind == "" || kind == ErrorKindServerError || kind == ErrorKindRateLimited
+}
+
+// readResponse reads the body of resp, returning an *Error carrying the server message for non-2xx statuses.
+func readResponse(endpoint string, resp *http.Response) ([]byte, error) {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var envelope struct {
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(body, &envelope)
+		return nil, newError(endpoint, resp.StatusCode, envelope.Message)
+	}
+
+	return body, nil
+}
+
+// decodeApiResponse decodes the {status, message, data} envelope used by most endpoints and returns
+// its data, or an *Error when the API reports status false.
+func decodeApiResponse[T any](endpoint string, resp *http.Response) (T, error) {
+	var response apiResponse[T]
+
+	body, err := readResponse(endpoint, resp)
+	if err != nil {
+		return response.Data, err
+	}
+
+	if err := json.Unmarshal(body, &response); err != nil {
+		return response.Data, fmt.Errorf("%s: invalid response: %w", endpoint, err)
+	}
+
+	if !response.Status {
+		return response.Data, newError(endpoint, resp.StatusCode, response.Message)
+	}
+
+	return response.Data, nil
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"ClassicAddonManager/backend/httpclient"
+	"ClassicAddonManager/backend/logger"
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+func (r *HTTPRegistry) GetAddonManifest(ctx context.Context, validators CacheValidators) (ManifestResponse, error) {
+	endpoint := "/addons"
+
+	req, err := http.NewRequestWithContext(ctx, "GET", r.baseURL+endpoint, nil)
+	if err != nil {
+		logger.Error("GetAddonManifest Error:", err)
+		return ManifestResponse{}, err
+	}
+	req.Header.Set("X-Client", GetApiClientHeader())
+	if validators.ETag != "" {
+		req.Header.Set("If-None-Match", validators.ETag)
+	}
+	if validators.LastModified != "" {
+		req.Header.Set("If-Modified-Since", validators.LastModified)
+	}
+
+	resp, err := httpclient.Do(req)
+	if err != nil {
+		logger.Error("GetAddonManifest Error:", err)
+		return ManifestResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return ManifestResponse{NotModified: true, Validators: validators}, nil
+	}
+
+	body, err := readResponse(endpoint, resp)
+	if err != nil {
+		logger.Error("GetAddonManifest Error:", err)
+		return ManifestResponse{}, err
+	}
+
+	response := ManifestResponse{
+		Validators: CacheValidators{
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+		},
+	}
+	if err := json.Unmarshal(body, &response.Addons); err != nil {
+		logger.Error("GetAddonManifest Error:", err)
+		return ManifestResponse{}, err
+	}
+
+	return response, nil
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"ClassicAddonManager/backend/config"
+	"ClassicAddonManager/backend/shared"
+	"ClassicAddonManager/backend/util"
+	"context"
+	"strings"
+	"sync"
+)
+
+// Registry is the source of addon manifests, releases and account operations.
+// Every network call the manager makes to the addon registry goes through it,
+// so a local mirror or a stand-in server can be used by swapping the implementation.
+type Registry interface {
+	BaseURL() string
+	GetAddonManifest(ctx context.Context, validators CacheValidators) (ManifestResponse, error)
+	GetAddonRelease(ctx context.Context, name string, version string, channel Channel) (Release, error)
+	GetAddonReleases(ctx context.Context, name string, page int, perPage int) (ReleasePage, error)
+	GetLatestReleasesBulk(ctx context.Context, names []string, channels map[string]Channel) (map[string]Release, error)
+	GetLatestApplicationRelease(ctx context.Context) (ApplicationRelease, error)
+	GetSubscribedAddons(ctx context.Context) ([]shared.AddonManifest, error)
+	SubscribeToAddon(ctx context.Context, name string) error
+	UnsubscribeFromAddon(ctx context.Context, name string) error
+	DownloadAddon(ctx context.Context, name string, version string, channel Channel, dest string, onProgress util.ProgressFunc) error
+}
+
+var (
+	registry   Registry
+	registryMu sync.RWMutex
+)
+
+// GetRegistry returns the active registry, creating an HTTP registry for the
+// configured API URL on first use.
+func GetRegistry() Registry {
+	registryMu.RLock()
+	r := registry
+	registryMu.RUnlock()
+	if r != nil {
+		return r
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if registry == nil {
+		registry = NewHTTPRegistry(config.GetApiURL())
+	}
+	return registry
+}
+
+// SetRegistry replaces the active registry.
+func SetRegistry(r Registry) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry = r
+}
+
+// HTTPRegistry talks to the addon registry HTTP API at BaseURL.
+type HTTPRegistry struct {
+	baseURL string
+}
+
+func NewHTTPRegistry(baseURL string) *HTTPRegistry {
+	return &HTTPRegistry{baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+func (r *HTTPRegistry) BaseURL() string {
+	return r.baseURL
+}
+
+// GetAddonManifest fetches the addon manifest, sending validators so the registry can answer 304 Not Modified.
+func GetAddonManifest(ctx context.Context, validators CacheValidators) (ManifestResponse, error) {
+	return GetRegistry().GetAddonManifest(ctx, validators)
+}
+
+// GetAddonRelease fetches a release of an addon by tag, or the newest one on channel for "latest".
+func GetAddonRelease(ctx context.Context, name string, version string, channel Channel) (Release, error) {
+	return GetRegistry().GetAddonRelease(ctx, name, version, channel)
+}
+
+// GetAddonReleases fetches one page of an addon's release history, newest first. Pages start at 1.
+func GetAddonReleases(ctx context.Context, name string, page int, perPage int) (ReleasePage, error) {
+	return GetRegistry().GetAddonReleases(ctx, name, page, perPage)
+}
+
+// GetLatestReleasesBulk fetches the latest release information for multiple addons in a single request,
+// each on its channel from channels or on the stable channel when it has none.
+func GetLatestReleasesBulk(ctx context.Context, names []string, channels map[string]Channel) (map[string]Release, error) {
+	return GetRegistry().GetLatestReleasesBulk(ctx, names, channels)
+}
+
+func GetLatestApplicationRelease(ctx context.Context) (ApplicationRelease, error) {
+	return GetRegistry().GetLatestApplicationRelease(ctx)
+}
+
+func GetSubscribedAddons(ctx context.Context) ([]shared.AddonManifest, error) {
+	return GetRegistry().GetSubscribedAddons(ctx)
+}
+
+func SubscribeToAddon(ctx context.Context, name string) error {
+	return GetRegistry().SubscribeToAddon(ctx, name)
+}
+
+func UnsubscribeFromAddon(ctx context.Context, name string) error {
+	return GetRegistry().UnsubscribeFromAddon(ctx, name)
+}
+
+// DownloadAddon downloads the archive of the given addon version ("" or "latest" for the newest release on channel)
+// to dest, calling onProgress as bytes arrive when it is not nil.
+func DownloadAddon(ctx context.Context, name string, version string, channel Channel, dest string, onProgress util.ProgressFunc) error {
+	return GetRegistry().DownloadAddon(ctx, name, version, channel, dest, onProgress)
+}
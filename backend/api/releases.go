@@ -1,141 +1,162 @@
 package api
 
 import (
+	"ClassicAddonManager/backend/httpclient"
 	"ClassicAddonManager/backend/logger"
 	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
-	"io"
 	"net/http"
+	"net/url"
 	"strconv"
-	"time"
-
-	"github.com/mitchellh/mapstructure"
 )
 
-func GetAddonRelease(name string, version string) (Release, error) {
+func (r *HTTPRegistry) GetAddonRelease(ctx context.Context, name string, version string, channel Channel) (Release, error) {
+	endpoint := fmt.Sprintf("/addon/%s/release/%s", name, version)
 
-	url := fmt.Sprintf("%s/addon/%s/release/%s", ApiURL, name, version)
+	target := r.baseURL + endpoint
+	query := url.Values{}
+	channel.setQuery(query)
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", target, nil)
 	if err != nil {
 		return Release{}, err
 	}
 	req.Header.Set("X-Client", GetApiClientHeader())
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := httpclient.Do(req)
 	if err != nil {
 		return Release{}, err
 	}
 	defer resp.Body.Close()
 
-	// If the status code is not 200 here, there's likely no release
-	if resp.StatusCode != 200 {
-		logger.Error("GetAddonRelease Error: Status Code", errors.New(strconv.Itoa(resp.StatusCode)))
-		return Release{}, errors.New("no release found")
-	}
-
-	body, err := io.ReadAll(resp.Body)
+	data, err := decodeApiResponse[releaseData](endpoint, resp)
 	if err != nil {
 		logger.Error("GetAddonRelease Error:", err)
 		return Release{}, err
 	}
 
-	var apiResponse ApiResponse
-
-	if err := json.Unmarshal(body, &apiResponse); err != nil {
-		logger.Error("GetAddonRelease Error:", err)
-		return Release{}, err
+	release := data.toRelease()
+	if channel.IsBranch() {
+		release.Branch = channel.Branch
 	}
+	return release, nil
+}
 
-	if !apiResponse.Status {
-		logger.Warn("GetAddonRelease status false: " + apiResponse.Message)
-		return Release{}, errors.New(apiResponse.Message)
-	}
+const (
+	defaultReleasesPerPage = 20
+	maxReleasesPerPage     = 100
+)
 
-	data := apiResponse.Data.(map[string]any)
-	r := data["release"].(map[string]any)
-	release := Release{
-		ZipballUrl:  r["zipball_url"].(string),
-		TagName:     r["tag_name"].(string),
-		Body:        r["body"].(string),
-		PublishedAt: time.Time{},
-		Tag:         Tag{},
+// GetAddonReleases fetches one page of an addon's release history. Pages start at 1.
+func (r *HTTPRegistry) GetAddonReleases(ctx context.Context, name string, page int, perPage int) (ReleasePage, error) {
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 {
+		perPage = defaultReleasesPerPage
 	}
+	if perPage > maxReleasesPerPage {
+		perPage = maxReleasesPerPage
+	}
+
+	endpoint := fmt.Sprintf("/addon/%s/releases", name)
+	query := url.Values{}
+	query.Set("page", strconv.Itoa(page))
+	query.Set("per_page", strconv.Itoa(perPage))
 
-	// Parse time from data.release.published_at
-	publishedAtStr := r["published_at"].(string)
-	release.PublishedAt, err = time.Parse(time.RFC3339, publishedAtStr)
+	req, err := http.NewRequestWithContext(ctx, "GET", r.baseURL+endpoint+"?"+query.Encode(), nil)
 	if err != nil {
-		logger.Error("GetAddonRelease Error:", err)
-		return Release{}, err
+		return ReleasePage{}, err
+	}
+	req.Header.Set("X-Client", GetApiClientHeader())
+	resp, err := httpclient.Do(req)
+	if err != nil {
+		return ReleasePage{}, err
 	}
+	defer resp.Body.Close()
 
-	tag := Tag{}
-	err = mapstructure.Decode(data["tag"], &tag)
+	releases, err := decodeApiResponse[[]Release](endpoint, resp)
 	if err != nil {
-		logger.Error("GetAddonRelease Error:", err)
-		return Release{}, err
+		logger.Error("GetAddonReleases Error:", err)
+		return ReleasePage{}, err
 	}
 
-	release.Tag = tag
-	return release, nil
+	// The registry lists a release once per tag it was published under, keep the first of each
+	seen := make(map[string]struct{}, len(releases))
+	unique := releases[:0]
+	for _, release := range releases {
+		if _, ok := seen[release.TagName]; ok {
+			continue
+		}
+		seen[release.TagName] = struct{}{}
+		unique = append(unique, release)
+	}
+
+	result := ReleasePage{
+		Releases: unique,
+		Page:     page,
+		PerPage:  perPage,
+		HasMore:  len(releases) == perPage,
+	}
+
+	// Servers that ignore the pagination parameters return the full history, page it here instead
+	if len(releases) > perPage {
+		start := min((page-1)*perPage, len(unique))
+		end := min(start+perPage, len(unique))
+		result.Releases = unique[start:end]
+		result.HasMore = end < len(unique)
+	}
+
+	return result, nil
 }
 
-func GetLatestApplicationRelease() (ApplicationRelease, error) {
-	url := ApiURL + "/latest_application_release"
+func (r *HTTPRegistry) GetLatestApplicationRelease(ctx context.Context) (ApplicationRelease, error) {
+	endpoint := "/latest_application_release"
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", r.baseURL+endpoint, nil)
 	if err != nil {
 		return ApplicationRelease{}, err
 	}
 	req.Header.Set("X-Client", GetApiClientHeader())
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := httpclient.Do(req)
 	if err != nil {
 		return ApplicationRelease{}, err
 	}
 	defer resp.Body.Close()
 
-	// If the status code is not 200 here, there's likely no release
-	if resp.StatusCode != 200 {
-		logger.Error("GetLatestApplicationRelease Error: Status Code", errors.New(strconv.Itoa(resp.StatusCode)))
-		return ApplicationRelease{}, errors.New("no release found")
-	}
-
-	body, err := io.ReadAll(resp.Body)
+	release, err := decodeApiResponse[ApplicationRelease](endpoint, resp)
 	if err != nil {
 		logger.Error("GetLatestApplicationRelease Error:", err)
 		return ApplicationRelease{}, err
 	}
 
-	var apiResponse ApiResponse
-
-	if err := json.Unmarshal(body, &apiResponse); err != nil {
-		logger.Error("GetLatestApplicationRelease Error:", err)
-		return ApplicationRelease{}, err
-	}
-
-	if !apiResponse.Status {
-		logger.Warn("GetLatestApplicationRelease status false: " + apiResponse.Message)
-		return ApplicationRelease{}, errors.New(apiResponse.Message)
-	}
-
-	data := apiResponse.Data.(map[string]any)
-	release := ApplicationRelease{
-		Version: data["version"].(string),
-		Url:     data["url"].(string),
-	}
-
 	return release, nil
 }
 
 // GetLatestReleasesBulk fetches the latest release information for multiple addons via a single POST request.
-func GetLatestReleasesBulk(names []string) (map[string]Release, error) {
+// Addons missing from channels are looked up on the stable channel.
+func (r *HTTPRegistry) GetLatestReleasesBulk(ctx context.Context, names []string, channels map[string]Channel) (map[string]Release, error) {
+	endpoint := "/latest_releases"
+
+	// Only send the addons that aren't on the stable channel
+	nonStable := make(map[string]Channel)
+	for name, channel := range channels {
+		if channel.Kind != "" && channel.Kind != ChannelStable {
+			nonStable[name] = channel
+		}
+	}
+
 	// Prepare the request body
-	requestBody, err := json.Marshal(map[string][]string{
-		"addons": names,
+	requestBody, err := json.Marshal(struct {
+		Addons   []string           `json:"addons"`
+		Channels map[string]Channel `json:"channels,omitempty"`
+	}{
+		Addons:   names,
+		Channels: nonStable,
 	})
 	if err != nil {
 		logger.Error("GetLatestReleasesBulk Error marshaling request body:", err)
@@ -143,8 +164,7 @@ func GetLatestReleasesBulk(names []string) (map[string]Release, error) {
 	}
 
 	// Create and send the POST request
-	url := ApiURL + "/latest_releases"
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(requestBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", r.baseURL+endpoint, bytes.NewBuffer(requestBody))
 	if err != nil {
 		logger.Error("GetLatestReleasesBulk Error creating request:", err)
 		return nil, err
@@ -152,60 +172,27 @@ func GetLatestReleasesBulk(names []string) (map[string]Release, error) {
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("X-Client", GetApiClientHeader())
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := httpclient.Do(req)
 	if err != nil {
 		logger.Error("GetLatestReleasesBulk Error sending request:", err)
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	// Handle non-200 responses
-	if resp.StatusCode != 200 {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		errMsg := "GetLatestReleasesBulk Error: Status Code " + strconv.Itoa(resp.StatusCode)
-		if len(bodyBytes) > 0 {
-			errMsg += " Body: " + string(bodyBytes)
-		}
-		logger.Error(errMsg, errors.New("unexpected status code"))
-		return nil, errors.New("failed to fetch bulk releases, status: " + strconv.Itoa(resp.StatusCode))
-	}
-
-	// Parse the API response
-	var apiResponse struct {
-		Status  bool   `json:"status"`
-		Message string `json:"message"`
-		Data    map[string]struct {
-			Release struct {
-				ZipballUrl  string    `json:"zipball_url"`
-				TagName     string    `json:"tag_name"`
-				Body        string    `json:"body"`
-				PublishedAt time.Time `json:"published_at"`
-			} `json:"release"`
-			Tag Tag `json:"tag"`
-		} `json:"data"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
-		logger.Error("GetLatestReleasesBulk Error unmarshaling response:", err)
+	data, err := decodeApiResponse[map[string]releaseData](endpoint, resp)
+	if err != nil {
+		logger.Error("GetLatestReleasesBulk Error:", err)
 		return nil, err
 	}
 
-	if !apiResponse.Status {
-		logger.Warn("GetLatestReleasesBulk API status false: " + apiResponse.Message)
-		return nil, errors.New(apiResponse.Message)
-	}
-
 	// Convert the response data into the expected format
-	releases := make(map[string]Release)
-	for name, data := range apiResponse.Data {
-		releases[name] = Release{
-			ZipballUrl:  data.Release.ZipballUrl,
-			TagName:     data.Release.TagName,
-			Body:        data.Release.Body,
-			PublishedAt: data.Release.PublishedAt,
-			Tag:         data.Tag,
+	releases := make(map[string]Release, len(data))
+	for name, d := range data {
+		release := d.toRelease()
+		if channel := nonStable[name]; channel.IsBranch() {
+			release.Branch = channel.Branch
 		}
+		releases[name] = release
 	}
 
 	return releases, nil
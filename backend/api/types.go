@@ -1,11 +1,43 @@
 package api
 
-import "time"
+import (
+	"ClassicAddonManager/backend/shared"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
 
-type ApiResponse struct {
+// apiResponse is the envelope most registry endpoints wrap their data in.
+type apiResponse[T any] struct {
 	Status  bool   `json:"status"`
 	Message string `json:"message"`
-	Data    any    `json:"data"`
+	Data    T      `json:"data"`
+}
+
+// releaseData is a release as returned by the release endpoints, with its tag alongside rather than inside it.
+type releaseData struct {
+	Release struct {
+		ZipballUrl  string    `json:"zipball_url"`
+		TagName     string    `json:"tag_name"`
+		Body        string    `json:"body"`
+		PublishedAt time.Time `json:"published_at"`
+		Sha256      string    `json:"sha256"`
+		Signature   string    `json:"signature"`
+	} `json:"release"`
+	Tag Tag `json:"tag"`
+}
+
+func (d releaseData) toRelease() Release {
+	return Release{
+		ZipballUrl:  d.Release.ZipballUrl,
+		TagName:     d.Release.TagName,
+		Body:        d.Release.Body,
+		PublishedAt: d.Release.PublishedAt,
+		Sha256:      d.Release.Sha256,
+		Signature:   d.Release.Signature,
+		Tag:         d.Tag,
+	}
 }
 
 type Release struct {
@@ -13,7 +45,63 @@ type Release struct {
 	TagName     string    `json:"tag_name"`
 	Body        string    `json:"body"`
 	PublishedAt time.Time `json:"published_at"`
-	Tag         Tag       `json:"tag"`
+	// Sha256 is the hex encoded SHA-256 of the release archive, empty when the registry doesn't provide one.
+	Sha256 string `json:"sha256,omitempty"`
+	// Signature is a minisign detached signature of the release archive.
+	Signature string `json:"signature,omitempty"`
+	Tag       Tag    `json:"tag"`
+	// Branch is set when the release is the head of a tracked branch rather than a tagged release.
+	// Tag.Sha is then the commit it was built from.
+	Branch string `json:"branch,omitempty"`
+}
+
+// Release channel kinds, selecting which releases of an addon are installed.
+const (
+	// ChannelStable follows tagged releases. It is the default.
+	ChannelStable = "stable"
+	// ChannelPrerelease follows tagged releases including prereleases.
+	ChannelPrerelease = "prerelease"
+	// ChannelBranch follows the head commit of a named branch.
+	ChannelBranch = "branch"
+)
+
+// Channel is the release channel an addon is tracked on. The zero value is the stable channel.
+type Channel struct {
+	Kind   string `json:"kind,omitempty"`
+	Branch string `json:"branch,omitempty"`
+}
+
+// IsBranch reports whether the channel follows a branch head instead of tagged releases.
+func (c Channel) IsBranch() bool {
+	return c.Kind == ChannelBranch
+}
+
+// Validate checks that the channel kind is known and that a branch is named exactly when one is followed.
+func (c Channel) Validate() error {
+	switch c.Kind {
+	case "", ChannelStable, ChannelPrerelease:
+		if c.Branch != "" {
+			return fmt.Errorf("a branch can only be set on the %s channel", ChannelBranch)
+		}
+	case ChannelBranch:
+		if c.Branch == "" {
+			return errors.New("no branch given to track")
+		}
+	default:
+		return fmt.Errorf("unknown release channel %q", c.Kind)
+	}
+	return nil
+}
+
+// setQuery adds the parameters selecting the channel to a registry request. Stable adds none.
+func (c Channel) setQuery(query url.Values) {
+	switch c.Kind {
+	case ChannelPrerelease:
+		query.Set("channel", ChannelPrerelease)
+	case ChannelBranch:
+		query.Set("channel", ChannelBranch)
+		query.Set("branch", c.Branch)
+	}
 }
 
 type Tag struct {
@@ -26,4 +114,28 @@ type Tag struct {
 type ApplicationRelease struct {
 	Version string `json:"version"`
 	Url     string `json:"url"`
+	// Signature is a minisign detached signature of the file at Url.
+	Signature string `json:"signature,omitempty"`
+}
+
+// CacheValidators are the HTTP validators used to revalidate a cached response.
+type CacheValidators struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+}
+
+// ManifestResponse is the result of a conditional addon manifest request.
+// When NotModified is set, Addons is empty and the cached copy is still current.
+type ManifestResponse struct {
+	Addons      []shared.AddonManifest
+	Validators  CacheValidators
+	NotModified bool
+}
+
+// ReleasePage is one page of an addon's release history, newest first.
+type ReleasePage struct {
+	Releases []Release `json:"releases"`
+	Page     int       `json:"page"`
+	PerPage  int       `json:"perPage"`
+	HasMore  bool      `json:"hasMore"`
 }
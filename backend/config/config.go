@@ -8,11 +8,18 @@ import (
 	"path/filepath"
 	"regexp"
 	"strconv"
+	"strings"
 
 	"github.com/spf13/viper"
 	"github.com/sqweek/dialog"
 )
 
+// DefaultApiURL is the official addon registry used when no override is configured.
+const DefaultApiURL = "https://aac.gaijin.dev"
+
+// ApiURLEnv is the environment variable that overrides the registry URL from config.toml.
+const ApiURLEnv = "CAM_API_URL"
+
 func LoadConfig() error {
 	err := getOrCreateConfig()
 	if err != nil {
@@ -203,6 +210,50 @@ func GetAddonDir() string {
 	return filepath.Join(GetAACDir(), "Addon")
 }
 
+// GetApiURL returns the base URL of the addon registry. The CAM_API_URL environment variable
+// takes precedence over general.apiurl in config.toml, which in turn overrides DefaultApiURL.
+func GetApiURL() string {
+	url := os.Getenv(ApiURLEnv)
+	if url == "" {
+		url = viper.GetString("general.apiurl")
+	}
+	if url == "" {
+		return DefaultApiURL
+	}
+	return strings.TrimRight(url, "/")
+}
+
+// registryConfig is a [[registries]] entry of config.toml. Registries are an array of tables rather than
+// a table keyed by URL because viper splits keys on dots, which every URL contains.
+type registryConfig struct {
+	URL        string   `mapstructure:"url"`
+	PublicKeys []string `mapstructure:"publickeys"`
+}
+
+// GetRegistryPublicKeys returns the minisign public keys configured for the registry at url, as in
+//
+//	[[registries]]
+//	url = "https://aac.gaijin.dev"
+//	publickeys = ["RWQ..."]
+//
+// Keys are only trusted for the registry they are listed under, so switching general.apiurl to another
+// registry doesn't carry trust over to it.
+func GetRegistryPublicKeys(url string) []string {
+	var registries []registryConfig
+	if err := viper.UnmarshalKey("registries", &registries); err != nil {
+		logger.Error("Could not read registries from config:", err)
+		return nil
+	}
+
+	url = strings.TrimRight(url, "/")
+	for _, registry := range registries {
+		if strings.EqualFold(strings.TrimRight(registry.URL, "/"), url) {
+			return registry.PublicKeys
+		}
+	}
+	return nil
+}
+
 func GetBool(option string) bool {
 	return viper.GetBool(option)
 }
@@ -226,6 +277,29 @@ func SetString(option string, value string) {
 	logger.Info("Set config option: " + option + " to " + value)
 }
 
+func GetInt(option string) int {
+	return viper.GetInt(option)
+}
+
+func SetInt(option string, value int) {
+	viper.Set(option, value)
+	_ = SaveConfig()
+}
+
+func IsSet(option string) bool {
+	return viper.IsSet(option)
+}
+
+// UnmarshalKey decodes option, such as an array of tables, into out.
+func UnmarshalKey(option string, out any) error {
+	return viper.UnmarshalKey(option, out)
+}
+
+func Set(option string, value any) {
+	viper.Set(option, value)
+	_ = SaveConfig()
+}
+
 func GetAll() map[string]any {
 	return viper.AllSettings()
 }
@@ -0,0 +1,163 @@
+package httpclient
+
+import (
+	"ClassicAddonManager/backend/logger"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// requestTimeout bounds a single API request attempt, including reading the body.
+	requestTimeout = 30 * time.Second
+	maxRetries     = 3
+	baseBackoff    = 500 * time.Millisecond
+	maxBackoff     = 8 * time.Second
+	// maxRetryAfter caps how long a 429 Retry-After header can make us wait.
+	maxRetryAfter = 60 * time.Second
+)
+
+// transport is shared by every client so connections are pooled across API calls and downloads.
+var transport = &http.Transport{
+	Proxy: http.ProxyFromEnvironment,
+	DialContext: (&net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}).DialContext,
+	TLSHandshakeTimeout:   10 * time.Second,
+	ResponseHeaderTimeout: 30 * time.Second,
+	IdleConnTimeout:       90 * time.Second,
+	MaxIdleConns:          20,
+	MaxIdleConnsPerHost:   10,
+}
+
+var apiClient = &http.Client{
+	Transport: transport,
+	Timeout:   requestTimeout,
+}
+
+// downloadClient has no overall timeout since archives can take a while on slow connections.
+// Stalled servers are caught by the transport timeouts and callers cancel through the request context.
+var downloadClient = &http.Client{
+	Transport: transport,
+}
+
+// Do sends an API request with a per-attempt timeout, retrying network errors,
+// 5xx responses and 429 responses with exponential backoff.
+func Do(req *http.Request) (*http.Response, error) {
+	return do(apiClient, req)
+}
+
+// Download sends a request for a potentially large body. It retries like Do
+// but does not limit how long reading the body may take.
+func Download(req *http.Request) (*http.Response, error) {
+	return do(downloadClient, req)
+}
+
+func do(client *http.Client, req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	for attempt := 0; ; attempt++ {
+		attemptReq := req
+		if attempt > 0 {
+			var err error
+			attemptReq, err = rewind(req)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := client.Do(attemptReq)
+		if attempt >= maxRetries || ctx.Err() != nil || !shouldRetry(resp, err) {
+			return resp, err
+		}
+
+		delay := backoff(attempt)
+		if resp != nil {
+			if retryAfter, ok := parseRetryAfter(resp); ok {
+				delay = retryAfter
+			}
+			// Drain so the connection can be reused
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+			logger.Warn(fmt.Sprintf("%s %s returned %d, retrying in %s", req.Method, req.URL, resp.StatusCode, delay))
+		} else {
+			logger.Warn(fmt.Sprintf("%s %s failed: %s, retrying in %s", req.Method, req.URL, err, delay))
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// rewind returns a copy of req with a fresh body so it can be sent again.
+func rewind(req *http.Request) (*http.Request, error) {
+	clone := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return clone, nil
+	}
+	if req.GetBody == nil {
+		return nil, fmt.Errorf("cannot retry %s %s: request body is not replayable", req.Method, req.URL)
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	clone.Body = body
+	return clone, nil
+}
+
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// backoff returns the delay before the given retry, doubling each attempt with up to 50% jitter.
+func backoff(attempt int) time.Duration {
+	delay := baseBackoff << attempt
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// parseRetryAfter reads the Retry-After header of a 429 response, which is either
+// a number of seconds or an HTTP date.
+func parseRetryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	var delay time.Duration
+	if seconds, err := strconv.Atoi(value); err == nil {
+		delay = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(value); err == nil {
+		delay = time.Until(date)
+	} else {
+		return 0, false
+	}
+
+	if delay < 0 {
+		delay = 0
+	}
+	if delay > maxRetryAfter {
+		delay = maxRetryAfter
+	}
+	return delay, true
+}
@@ -8,6 +8,7 @@ import (
 	"ClassicAddonManager/backend/logger"
 	"ClassicAddonManager/backend/shared"
 	"ClassicAddonManager/backend/util"
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
@@ -24,8 +25,8 @@ func (s *ApplicationService) GetVersion() string {
 	return shared.Version
 }
 
-func (s *ApplicationService) GetLatestRelease() (api.ApplicationRelease, error) {
-	release, err := api.GetLatestApplicationRelease()
+func (s *ApplicationService) GetLatestRelease(ctx context.Context) (api.ApplicationRelease, error) {
+	release, err := api.GetLatestApplicationRelease(ctx)
 	if err != nil {
 		logger.Error("Error getting latest application release:", err)
 		return api.ApplicationRelease{}, err
@@ -33,6 +34,11 @@ func (s *ApplicationService) GetLatestRelease() (api.ApplicationRelease, error)
 	return release, nil
 }
 
+// GetApiURL returns the registry base URL so the frontend talks to the same server as the backend.
+func (s *ApplicationService) GetApiURL() string {
+	return api.BaseURL()
+}
+
 func (s *ApplicationService) GetAuthSession() shared.AuthSessionState {
 	return shared.AuthSessionState{Token: auth.GetToken()}
 }
@@ -45,7 +51,7 @@ func (s *ApplicationService) ClearAuthToken() error {
 	return auth.DeleteFromDisk()
 }
 
-func (s *ApplicationService) SelfUpdate(updateURL string) error {
+func (s *ApplicationService) SelfUpdate(ctx context.Context, updateURL string) error {
 	// Current exe path
 	exePath, err := os.Executable()
 	if err != nil {
@@ -73,12 +79,26 @@ func (s *ApplicationService) SelfUpdate(updateURL string) error {
 		logger.Info("Removed existing update file")
 	}
 
-	err = util.DownloadFile(updateURL, newExePath)
+	err = util.DownloadFile(ctx, updateURL, newExePath, util.DownloadOptions{
+		OnProgress: func(downloaded int64, total int64) {
+			s.App.Event.Emit("applicationDownloadProgress", shared.DownloadProgress{
+				Name:       "ClassicAddonManager",
+				Downloaded: downloaded,
+				Total:      total,
+			})
+		},
+	})
 	if err != nil {
 		logger.Error("Error downloading update:", err)
 		return err
 	}
 
+	if err := verifyUpdateSignature(ctx, updateURL, newExePath); err != nil {
+		logger.Error("Refusing update:", err)
+		_ = os.Remove(newExePath)
+		return err
+	}
+
 	// Create update batch script
 	scriptPath := filepath.Join(tmpDir, "update.bat")
 	scriptContent := fmt.Sprintf(`@echo off
@@ -110,6 +130,27 @@ exit
 	return nil
 }
 
+// verifyUpdateSignature checks a downloaded application update against the signature published with
+// the latest application release, which must be the release the update was downloaded from.
+func verifyUpdateSignature(ctx context.Context, updateURL string, path string) error {
+	var sig string
+	release, err := api.GetLatestApplicationRelease(ctx)
+	if err != nil {
+		logger.Error("Error getting latest application release for signature check:", err)
+	} else if release.Url == updateURL {
+		sig = release.Signature
+	}
+
+	verified, err := util.NewSignatureVerifier().VerifyFile(path, sig)
+	if err != nil {
+		return fmt.Errorf("update signature check failed: %w", err)
+	}
+	if !verified {
+		logger.Warn("No trusted signature for update " + updateURL + ", installing unverified")
+	}
+	return nil
+}
+
 func (s *ApplicationService) SelectAndValidateDocsPath(title string) (string, error) {
 	dialog := s.App.Dialog.OpenFileWithOptions(&application.OpenFileDialogOptions{
 		Title:                title,
@@ -137,6 +178,16 @@ func (s *ApplicationService) SettingsSetAutoDetectPath(enabled bool) {
 	config.SetBool("general.autodetectpath", enabled)
 }
 
+// SettingsSetBackupRetention sets how many backups are kept per addon, 0 disables backups.
+func (s *ApplicationService) SettingsSetBackupRetention(count int) {
+	config.SetInt("general.backupretention", max(count, 0))
+}
+
+// SettingsSetUpdateWorkers sets how many archives are downloaded at once when updating all addons.
+func (s *ApplicationService) SettingsSetUpdateWorkers(count int) {
+	config.SetInt("general.updateworkers", count)
+}
+
 func (s *ApplicationService) GetConfig() map[string]any {
 	return config.GetAll()
 }
@@ -6,7 +6,9 @@ import (
 	"ClassicAddonManager/backend/config"
 	"ClassicAddonManager/backend/file"
 	"ClassicAddonManager/backend/logger"
+	"ClassicAddonManager/backend/shared"
 	"ClassicAddonManager/backend/util"
+	"context"
 	"path/filepath"
 )
 
@@ -25,37 +27,49 @@ func (s *LocalAddonService) IsInstalled(name string) bool {
 }
 
 func (s *LocalAddonService) GetAllInstalledAddonNames() []string {
-	return addon.GetInstalledAddonNames()
+	return addon.GetAllAddonNames()
 }
 
-func (s *LocalAddonService) UninstallAddon(name string) bool {
-	if !addon.IsInstalled(name) {
-		return false
+// UninstallAddon removes an addon. If other installed addons depend on it, mode decides whether the
+// uninstall is refused (""), the dependents are removed too ("cascade") or the addon is kept and only
+// unmanaged ("unmanage").
+func (s *LocalAddonService) UninstallAddon(ctx context.Context, name string, mode string) shared.UninstallResult {
+	wasManaged := make(map[string]bool, len(addon.LocalAddons))
+	for managedName := range addon.LocalAddons {
+		wasManaged[managedName] = true
 	}
 
-	ok, err := file.RemoveDir(filepath.Join(config.GetAddonDir(), name))
-	if err != nil {
-		logger.Error("Error removing addon directory:", err)
-		return false
+	result := addon.UninstallAddon(name, mode)
+	if result.Error != "" {
+		logger.Warn("Uninstall of " + name + " failed: " + result.Error)
 	}
-	if !ok {
-		return false
+
+	var unsubscribe []string
+	for _, removed := range result.Removed {
+		if wasManaged[removed] {
+			unsubscribe = append(unsubscribe, removed)
+		}
 	}
+	updateSubscriptions(ctx, nil, unsubscribe)
 
-	// Remove from managed addons
-	wasRemoved := addon.RemoveManagedAddon(name)
+	return result
+}
 
-	// Remove from addons.txt
-	if err := addon.RemoveFromAddonsTxt(name); err != nil {
-		logger.Error("Error removing addon from addons.txt:", err)
-		return false
-	}
+// GetOrphanedAddons lists addons that were installed as a dependency and that nothing installed needs any more.
+func (s *LocalAddonService) GetOrphanedAddons() []string {
+	return addon.FindOrphanedAddons()
+}
 
-	if wasRemoved {
-		api.UnsubscribeFromAddon(name)
+// RemoveOrphanedAddons uninstalls the orphaned addons the user confirmed.
+func (s *LocalAddonService) RemoveOrphanedAddons(ctx context.Context, names []string) shared.UninstallResult {
+	result := addon.RemoveOrphanedAddons(names)
+	if result.Error != "" {
+		logger.Warn("Removing orphaned addons failed: " + result.Error)
 	}
 
-	return true
+	updateSubscriptions(ctx, nil, result.Removed)
+
+	return result
 }
 
 func (s *LocalAddonService) InstallZipAddon(zipPath string) (string, error) {
@@ -66,6 +80,89 @@ func (s *LocalAddonService) UnmanageAddon(name string) bool {
 	return addon.RemoveManagedAddon(name)
 }
 
+// VerifyInstalledAddons re-hashes the files of every managed addon against what was recorded at install time.
+func (s *LocalAddonService) VerifyInstalledAddons() []addon.VerificationResult {
+	return addon.VerifyInstalledAddons()
+}
+
+// GetLocalModifications lists the files of a managed addon changed or added since it was installed, which
+// an update would overwrite.
+func (s *LocalAddonService) GetLocalModifications(name string) addon.VerificationResult {
+	return addon.GetLocalModifications(name)
+}
+
+// SetEnabled disables an addon so the game doesn't load it, or enables it again, keeping its files and
+// managed entry. Disabled addons are still checked for updates.
+func (s *LocalAddonService) SetEnabled(name string, enabled bool) error {
+	if err := addon.SetEnabled(name, enabled); err != nil {
+		logger.Error("Error changing enabled state of addon:", err)
+		return err
+	}
+	return nil
+}
+
+// SetPinned pins a managed addon to its installed version so it is never reported as outdated.
+func (s *LocalAddonService) SetPinned(name string, pinned bool) error {
+	return addon.SetAddonPinned(name, pinned)
+}
+
+// SkipVersion stops update notifications for one release of a managed addon. An empty version clears it.
+func (s *LocalAddonService) SkipVersion(name string, version string) error {
+	return addon.SetSkippedVersion(name, version)
+}
+
+// SetChannel chooses whether a managed addon follows stable releases, prereleases or the head of a branch.
+func (s *LocalAddonService) SetChannel(name string, channel api.Channel) error {
+	return addon.SetAddonChannel(name, channel)
+}
+
+// GetAddonBackups lists the backups taken of an addon before it was updated, newest first.
+func (s *LocalAddonService) GetAddonBackups(name string) []addon.Backup {
+	return addon.GetAddonBackups(name)
+}
+
+// RestoreAddonBackup puts back the files and managed entry of an addon from one of its backups.
+func (s *LocalAddonService) RestoreAddonBackup(name string, backupID string) error {
+	return addon.RestoreAddonBackup(name, backupID)
+}
+
+// GetAdoptionCandidates proposes a registry addon for each unmanaged addon that looks like a copy of one.
+// compareFiles downloads recent releases to compare their files, which gives a more reliable match.
+func (s *LocalAddonService) GetAdoptionCandidates(ctx context.Context, compareFiles bool) ([]addon.AdoptionCandidate, error) {
+	return addon.FindAdoptionCandidates(ctx, compareFiles)
+}
+
+// GetAdoptionCandidate proposes the registry addon an unmanaged addon is a copy of, or nil when there is none.
+func (s *LocalAddonService) GetAdoptionCandidate(ctx context.Context, name string, compareFiles bool) *addon.AdoptionCandidate {
+	candidate, ok := addon.FindAdoptionCandidate(ctx, name, compareFiles)
+	if !ok {
+		return nil
+	}
+	return &candidate
+}
+
+// AdoptAddon starts managing an unmanaged addon as the given registry addon and release, so it gets updates.
+func (s *LocalAddonService) AdoptAddon(ctx context.Context, name string, manifestName string, version string) error {
+	if err := addon.AdoptAddon(ctx, name, manifestName, version); err != nil {
+		logger.Error("Error adopting addon:", err)
+		return err
+	}
+
+	updateSubscriptions(ctx, []string{manifestName}, nil)
+	return nil
+}
+
+// GetPreserveRules lists the paths besides .data that are kept when an addon is updated.
+func (s *LocalAddonService) GetPreserveRules(name string) []string {
+	return addon.GetPreserveRules(name, filepath.Join(config.GetAddonDir(), name))
+}
+
+// SetPreserveRules overrides the paths kept when an addon is updated, in place of the ones it ships. An
+// empty list goes back to the ones it ships.
+func (s *LocalAddonService) SetPreserveRules(name string, rules []string) {
+	addon.SetPreserveRules(name, rules)
+}
+
 func (s *LocalAddonService) ResetSettings() error {
 	err := addon.ResetAddonSettings()
 	if err != nil {
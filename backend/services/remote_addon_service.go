@@ -5,46 +5,113 @@ import (
 	"ClassicAddonManager/backend/api"
 	"ClassicAddonManager/backend/logger"
 	"ClassicAddonManager/backend/shared"
-	"fmt"
+	"context"
+	"errors"
+	"slices"
 	"sort"
+	"strings"
 )
 
 type RemoteAddonService struct{}
 
-const maxDependencyDepth = 10
+func (s *RemoteAddonService) GetAddonManifest(ctx context.Context) []shared.AddonManifest {
+	return addon.GetAddonManifest(ctx)
+}
 
-func (s *RemoteAddonService) GetAddonManifest() []shared.AddonManifest {
-	return addon.GetAddonManifest()
+// GetManifestStatus reports whether the addon manifest is a cached copy served while the registry is unreachable.
+func (s *RemoteAddonService) GetManifestStatus() addon.ManifestStatus {
+	return addon.GetManifestStatus()
 }
 
-func (s *RemoteAddonService) InstallAddon(ad shared.AddonManifest, version string) (bool, error) {
-	_, err := addon.InstallAddon(ad, version)
+func (s *RemoteAddonService) InstallAddon(ctx context.Context, ad shared.AddonManifest, version string) (bool, error) {
+	managedBefore := managedAddonNames()
+	_, err := addon.InstallAddon(ctx, ad, version, addon.InstallReasonExplicit)
 	if err != nil {
 		logger.Error("Error installing addon:", err)
 		return false, err
 	}
 
-	err = addon.AddToAddonsTxt(ad.Name)
+	updateSubscriptions(ctx, []string{ad.Name}, replacedAddons(managedBefore))
+	return true, nil
+}
+
+// UpdateAddon updates an addon. Its dependencies are resolved again first, so dependencies added by the new
+// release are installed before the addon itself is replaced, and the update is refused when their version
+// constraints can't be met. mode decides what happens to files changed since the addon was installed.
+func (s *RemoteAddonService) UpdateAddon(ctx context.Context, ad shared.AddonManifest, version string, mode string) (shared.InstallWithDependenciesResult, error) {
+	result := shared.InstallWithDependenciesResult{
+		DependencyWarnings: []string{},
+		Dependencies:       []shared.AddonInstallStatus{},
+		ConflictingAddons:  []string{},
+		OverwrittenPaths:   []string{},
+		MainAddon: shared.AddonInstallStatus{
+			Name:  ad.Name,
+			Alias: ad.Alias,
+		},
+	}
+
+	// Refuse before any dependency is installed
+	if err := addon.CheckLocalModifications(ad.Name, mode); err != nil {
+		logger.Error("Error updating addon:", err)
+		result.MainAddon.Error = err.Error()
+		return result, nil
+	}
+
+	resolution, err := addon.ResolveDependencies(ctx, ad)
 	if err != nil {
-		logger.Error(ad.Name+" - Error adding addon to addons.txt: ", err)
-		return false, err
+		logger.Error("Error resolving dependencies:", err)
+		return result, err
 	}
+	result.DependencyWarnings = resolution.Errors
 
-	return true, nil
-}
+	if len(resolution.Conflicts) > 0 {
+		err := addon.ConflictsError(resolution.Conflicts)
+		logger.Error("Error updating addon:", err)
+		result.MainAddon.Error = err.Error()
+		return result, nil
+	}
 
-func (s *RemoteAddonService) UpdateAddon(ad shared.AddonManifest, version string) (bool, error) {
-	_, err := addon.UpdateAddon(ad, version)
+	installedBefore := addon.GetAllAddonNames()
+	managedBefore := managedAddonNames()
+	dependencies, err := addon.InstallDependencies(ctx, resolution.Dependencies, mode)
+	result.Dependencies = dependencies
+	updateSubscriptions(ctx, installedDependencies(dependencies, installedBefore), replacedAddons(managedBefore))
+	if err != nil {
+		result.MainAddon.Error = err.Error()
+		return result, nil
+	}
+
+	overwritten, err := addon.UpdateAddon(ctx, ad, version, mode)
 	if err != nil {
 		logger.Error("Error updating addon:", err)
-		return false, err
+		result.MainAddon.Error = err.Error()
+		return result, nil
+	}
+	if overwritten != nil {
+		result.OverwrittenPaths = overwritten
 	}
 
-	return true, nil
+	result.MainAddon.Success = true
+	result.Success = true
+	return result, nil
+}
+
+// UpdateAll updates every managed addon with a newer release and reports the outcome for each of them.
+// mode decides what happens to files changed since an addon was installed.
+func (s *RemoteAddonService) UpdateAll(ctx context.Context, mode string) (shared.UpdateAllReport, error) {
+	installedBefore := addon.GetAllAddonNames()
+	managedBefore := managedAddonNames()
+	report, err := addon.UpdateAll(ctx, mode)
+	var installed []string
+	for _, result := range report.Results {
+		installed = append(installed, installedDependencies(result.Dependencies, installedBefore)...)
+	}
+	updateSubscriptions(ctx, installed, replacedAddons(managedBefore))
+	return report, err
 }
 
-func (s *RemoteAddonService) GetLatestRelease(name string) (api.Release, error) {
-	release, err := api.GetAddonRelease(name, "latest")
+func (s *RemoteAddonService) GetLatestRelease(ctx context.Context, name string) (api.Release, error) {
+	release, err := api.GetAddonRelease(ctx, name, "latest", addon.GetAddonChannel(name))
 	if err != nil {
 		logger.Error("Error getting latest release:", err)
 		return api.Release{}, err
@@ -52,110 +119,143 @@ func (s *RemoteAddonService) GetLatestRelease(name string) (api.Release, error)
 	return release, nil
 }
 
-func (s *RemoteAddonService) CheckAddonUpdatesBulk(names []string) (map[string]api.Release, error) {
-	releases, err := api.GetLatestReleasesBulk(names)
+// GetAddonReleases returns one page of an addon's release history so a specific version can be installed.
+func (s *RemoteAddonService) GetAddonReleases(ctx context.Context, name string, page int, perPage int) (api.ReleasePage, error) {
+	releases, err := api.GetAddonReleases(ctx, name, page, perPage)
 	if err != nil {
-		logger.Error("Service: Error from GetLatestReleasesBulk:", err)
-		return nil, err
+		logger.Error("Error getting addon releases:", err)
+		return api.ReleasePage{}, err
 	}
 	return releases, nil
 }
 
-func (s *RemoteAddonService) GetSubscribedAddons() ([]shared.AddonManifest, error) {
-	return api.GetSubscribedAddons()
+// GetChangelog returns the merged release notes between the installed version of an addon and version.
+func (s *RemoteAddonService) GetChangelog(ctx context.Context, name string, version string) (addon.Changelog, error) {
+	changelog, err := addon.GetChangelog(ctx, name, version)
+	if err != nil {
+		logger.Error("Error getting changelog:", err)
+		return addon.Changelog{}, err
+	}
+	return changelog, nil
 }
 
-func (s *RemoteAddonService) ResolveDependencies(ad shared.AddonManifest) (shared.DependencyResolutionResult, error) {
-	result := shared.DependencyResolutionResult{
-		Dependencies: []shared.DependencyInfo{},
-		Errors:       []string{},
+// CheckAddonUpdatesBulk returns the latest release of each named addon. Releases a managed addon doesn't
+// want, because it is pinned or the release was skipped, are left out.
+func (s *RemoteAddonService) CheckAddonUpdatesBulk(ctx context.Context, names []string) (map[string]api.Release, error) {
+	channels := make(map[string]api.Channel, len(names))
+	for _, name := range names {
+		channels[name] = addon.GetAddonChannel(name)
 	}
 
-	manifests := addon.GetAddonManifest()
-	if len(manifests) == 0 {
-		return result, fmt.Errorf("failed to fetch addon manifests")
+	releases, err := api.GetLatestReleasesBulk(ctx, names, channels)
+	if err != nil {
+		logger.Error("Service: Error from GetLatestReleasesBulk:", err)
+		return nil, err
 	}
 
-	manifestByName := make(map[string]shared.AddonManifest, len(manifests))
-	for _, manifest := range manifests {
-		manifestByName[manifest.Name] = manifest
+	for name, release := range releases {
+		if local := addon.FindLocalAddonByName(name); local != nil && !local.WantsUpdate(release) {
+			delete(releases, name)
+		}
 	}
+	return releases, nil
+}
 
-	if _, err := addon.ReadAddonsTxt(); err != nil {
-		logger.Error("ResolveDependencies: failed to read addons.txt:", err)
-	}
+func (s *RemoteAddonService) GetSubscribedAddons(ctx context.Context) ([]shared.AddonManifest, error) {
+	return api.GetSubscribedAddons(ctx)
+}
 
-	installedByName := make(map[string]struct{})
-	for _, name := range addon.GetInstalledAddonNames() {
-		installedByName[name] = struct{}{}
+// GetSubscriptionSync compares the account's subscribed addons with the managed addons installed locally.
+func (s *RemoteAddonService) GetSubscriptionSync(ctx context.Context) (shared.SubscriptionSyncReport, error) {
+	report := shared.SubscriptionSyncReport{
+		Missing:      []shared.AddonManifest{},
+		Unsubscribed: []string{},
 	}
 
-	dependencyByName := make(map[string]shared.DependencyInfo)
+	subscribed, err := api.GetSubscribedAddons(ctx)
+	if err != nil {
+		return report, err
+	}
 
-	var walk func(name string, depth int, lineage map[string]struct{})
-	walk = func(name string, depth int, lineage map[string]struct{}) {
-		if depth > maxDependencyDepth {
-			result.Errors = append(result.Errors, fmt.Sprintf("Maximum dependency depth exceeded for: %s", name))
-			return
-		}
+	if _, err := addon.ReadAddonsTxt(); err != nil {
+		logger.Error("GetSubscriptionSync: failed to read addons.txt:", err)
+	}
 
-		if _, circular := lineage[name]; circular {
-			result.Errors = append(result.Errors, fmt.Sprintf("Circular dependency detected: %s", name))
-			return
+	subscribedByName := make(map[string]struct{}, len(subscribed))
+	for _, manifest := range subscribed {
+		subscribedByName[manifest.Name] = struct{}{}
+		if !addon.IsInstalled(manifest.Name) {
+			report.Missing = append(report.Missing, manifest)
 		}
+	}
 
-		manifest, ok := manifestByName[name]
-		if !ok {
-			result.Errors = append(result.Errors, fmt.Sprintf("Addon %s not found in repository manifests", name))
-			return
+	for name, local := range addon.LocalAddons {
+		if _, ok := subscribedByName[name]; !ok && local.IsManaged {
+			report.Unsubscribed = append(report.Unsubscribed, name)
 		}
+	}
 
-		_, isInstalled := installedByName[name]
-		existing, exists := dependencyByName[name]
-		if !exists || depth > existing.Depth {
-			dependencyByName[name] = shared.DependencyInfo{
-				Manifest:    manifest,
-				IsInstalled: isInstalled,
-				Depth:       depth,
-			}
-		}
+	sort.Slice(report.Missing, func(i, j int) bool {
+		return report.Missing[i].Name < report.Missing[j].Name
+	})
+	sort.Strings(report.Unsubscribed)
 
-		nextLineage := make(map[string]struct{}, len(lineage)+1)
-		for key := range lineage {
-			nextLineage[key] = struct{}{}
-		}
-		nextLineage[name] = struct{}{}
+	return report, nil
+}
 
-		for _, depName := range manifest.Dependencies {
-			walk(depName, depth+1, nextLineage)
+// InstallSubscribedAddons installs the latest release of each given subscribed addon along with its dependencies.
+func (s *RemoteAddonService) InstallSubscribedAddons(ctx context.Context, manifests []shared.AddonManifest) []shared.InstallWithDependenciesResult {
+	results := make([]shared.InstallWithDependenciesResult, 0, len(manifests))
+	for _, manifest := range manifests {
+		result, err := s.InstallAddonWithDependencies(ctx, manifest, "latest")
+		if err != nil {
+			result = shared.InstallWithDependenciesResult{
+				DependencyWarnings: []string{},
+				Dependencies:       []shared.AddonInstallStatus{},
+				ConflictingAddons:  []string{},
+				OverwrittenPaths:   []string{},
+				MainAddon: shared.AddonInstallStatus{
+					Name:  manifest.Name,
+					Alias: manifest.Alias,
+					Error: err.Error(),
+				},
+			}
 		}
+		results = append(results, result)
 	}
+	return results
+}
 
-	rootLineage := map[string]struct{}{
-		ad.Name: {},
-	}
-	for _, depName := range ad.Dependencies {
-		walk(depName, 0, rootLineage)
-	}
-
-	dependencies := make([]shared.DependencyInfo, 0, len(dependencyByName))
-	for _, dependency := range dependencyByName {
-		dependencies = append(dependencies, dependency)
+// SubscribeToAddons subscribes the account to each of the given addons.
+func (s *RemoteAddonService) SubscribeToAddons(ctx context.Context, names []string) error {
+	var errs []error
+	for _, name := range names {
+		if err := api.SubscribeToAddon(ctx, name); err != nil {
+			errs = append(errs, err)
+		}
 	}
+	return errors.Join(errs...)
+}
 
-	sort.SliceStable(dependencies, func(i, j int) bool {
-		if dependencies[i].Depth != dependencies[j].Depth {
-			return dependencies[i].Depth > dependencies[j].Depth
+// UnsubscribeFromAddons removes the given addons from the account's subscriptions without touching local files.
+func (s *RemoteAddonService) UnsubscribeFromAddons(ctx context.Context, names []string) error {
+	var errs []error
+	for _, name := range names {
+		if err := api.UnsubscribeFromAddon(ctx, name); err != nil {
+			errs = append(errs, err)
 		}
-		return dependencies[i].Manifest.Name < dependencies[j].Manifest.Name
-	})
+	}
+	return errors.Join(errs...)
+}
 
-	result.Dependencies = dependencies
-	return result, nil
+// ResolveDependencies lists the dependencies of an addon, with the release of each that satisfies every
+// version constraint on it, and reports the dependencies no release can satisfy.
+func (s *RemoteAddonService) ResolveDependencies(ctx context.Context, ad shared.AddonManifest) (shared.DependencyResolutionResult, error) {
+	return addon.ResolveDependencies(ctx, ad)
 }
 
-func (s *RemoteAddonService) InstallAddonWithDependencies(ad shared.AddonManifest, version string) (shared.InstallWithDependenciesResult, error) {
-	resolutionResult, err := s.ResolveDependencies(ad)
+func (s *RemoteAddonService) InstallAddonWithDependencies(ctx context.Context, ad shared.AddonManifest, version string) (shared.InstallWithDependenciesResult, error) {
+	resolutionResult, err := s.ResolveDependencies(ctx, ad)
 	if err != nil {
 		return shared.InstallWithDependenciesResult{}, err
 	}
@@ -164,47 +264,37 @@ func (s *RemoteAddonService) InstallAddonWithDependencies(ad shared.AddonManifes
 		Success:            false,
 		DependencyWarnings: resolutionResult.Errors,
 		Dependencies:       []shared.AddonInstallStatus{},
+		ConflictingAddons:  []string{},
+		OverwrittenPaths:   []string{},
 		MainAddon: shared.AddonInstallStatus{
 			Name:  ad.Name,
 			Alias: ad.Alias,
 		},
 	}
 
-	for _, dep := range resolutionResult.Dependencies {
-		status := shared.AddonInstallStatus{
-			Name:  dep.Manifest.Name,
-			Alias: dep.Manifest.Alias,
-		}
-
-		if status.Alias == "" {
-			status.Alias = dep.Manifest.Name
-		}
-
-		if dep.IsInstalled {
-			status.Success = true
-			status.Skipped = true
-			result.Dependencies = append(result.Dependencies, status)
-			continue
-		}
+	if len(resolutionResult.Conflicts) > 0 {
+		result.MainAddon.Error = addon.ConflictsError(resolutionResult.Conflicts).Error()
+		return result, nil
+	}
 
-		ok, installErr := s.InstallAddon(dep.Manifest, "latest")
-		if installErr != nil || !ok {
-			status.Success = false
-			if installErr != nil {
-				status.Error = installErr.Error()
-			} else {
-				status.Error = "installation failed"
-			}
-			result.Dependencies = append(result.Dependencies, status)
-			result.MainAddon.Error = "dependency installation failed"
-			return result, nil
-		}
+	result.ConflictingAddons = findConflictingAddons(ad, resolutionResult.Dependencies)
+	if len(result.ConflictingAddons) > 0 {
+		result.MainAddon.Error = "conflicts with installed addon(s) " + strings.Join(result.ConflictingAddons, ", ")
+		return result, nil
+	}
 
-		status.Success = true
-		result.Dependencies = append(result.Dependencies, status)
+	// Nothing the user changed is overwritten without asking when installing a new addon
+	installedBefore := addon.GetAllAddonNames()
+	managedBefore := managedAddonNames()
+	dependencies, err := addon.InstallDependencies(ctx, resolutionResult.Dependencies, addon.ModificationsAbort)
+	result.Dependencies = dependencies
+	updateSubscriptions(ctx, installedDependencies(dependencies, installedBefore), replacedAddons(managedBefore))
+	if err != nil {
+		result.MainAddon.Error = err.Error()
+		return result, nil
 	}
 
-	ok, installErr := s.InstallAddon(ad, version)
+	ok, installErr := s.InstallAddon(ctx, ad, version)
 	if installErr != nil || !ok {
 		result.MainAddon.Success = false
 		if installErr != nil {
@@ -219,3 +309,22 @@ func (s *RemoteAddonService) InstallAddonWithDependencies(ad shared.AddonManifes
 	result.Success = true
 	return result, nil
 }
+
+// findConflictingAddons lists the installed addons that conflict with an addon or with one of the
+// dependencies that would be installed with it.
+func findConflictingAddons(ad shared.AddonManifest, dependencies []shared.DependencyInfo) []string {
+	conflicting := addon.FindConflictingAddons(ad)
+	for _, dep := range dependencies {
+		if dep.IsInstalled {
+			continue
+		}
+		for _, name := range addon.FindConflictingAddons(dep.Manifest) {
+			if !slices.Contains(conflicting, name) {
+				conflicting = append(conflicting, name)
+			}
+		}
+	}
+
+	sort.Strings(conflicting)
+	return conflicting
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"ClassicAddonManager/backend/addon"
+	"ClassicAddonManager/backend/api"
+	"ClassicAddonManager/backend/auth"
+	"ClassicAddonManager/backend/shared"
+	"context"
+	"slices"
+)
+
+// updateSubscriptions subscribes the signed in account to the addons that were just installed and
+// unsubscribes it from the ones that were just removed. Failures are logged by the registry and shouldn't
+// fail the install or removal that triggered them.
+func updateSubscriptions(ctx context.Context, installed []string, removed []string) {
+	if auth.GetToken() == "" {
+		return
+	}
+
+	for _, name := range installed {
+		_ = api.SubscribeToAddon(ctx, name)
+	}
+	for _, name := range removed {
+		_ = api.UnsubscribeFromAddon(ctx, name)
+	}
+}
+
+// managedAddonNames returns the managed addons, so the ones an install replaces can be told apart afterwards.
+func managedAddonNames() []string {
+	names := make([]string, 0, len(addon.LocalAddons))
+	for name := range addon.LocalAddons {
+		names = append(names, name)
+	}
+	return names
+}
+
+// replacedAddons returns the addons in managedBefore that are gone, which an install replaced. Their
+// subscriptions move to the addons that replaced them.
+func replacedAddons(managedBefore []string) []string {
+	var replaced []string
+	for _, name := range managedBefore {
+		if addon.FindLocalAddonByName(name) == nil {
+			replaced = append(replaced, name)
+		}
+	}
+	return replaced
+}
+
+// installedDependencies returns the dependencies in statuses that were installed successfully and aren't
+// among the addons installed before.
+func installedDependencies(statuses []shared.AddonInstallStatus, installedBefore []string) []string {
+	var installed []string
+	for _, status := range statuses {
+		if status.Success && !status.Skipped && !slices.Contains(installedBefore, status.Name) {
+			installed = append(installed, status.Name)
+		}
+	}
+	return installed
+}
@@ -24,17 +24,47 @@ type AddonManifest struct {
 	Kofi         *string   `json:"kofi,omitempty"`
 	AddedAt      time.Time `json:"added_at"`
 	Warning      *string   `json:"warning,omitempty"`
+	// Conflicts are addons that can't be installed together with this one
+	Conflicts []string `json:"conflicts"`
+	// Replaces are addons this one supersedes, such as the original of a fork
+	Replaces []string `json:"replaces"`
 }
 
 type DependencyInfo struct {
 	Manifest    AddonManifest `json:"manifest"`
 	IsInstalled bool          `json:"isInstalled"`
 	Depth       int           `json:"depth"`
+	// Version is the release to install, empty for the latest one. For an installed dependency it is only
+	// set when the installed version doesn't satisfy Constraint.
+	Version string `json:"version,omitempty"`
+	// Constraint combines the version constraints every dependent puts on the dependency.
+	Constraint string `json:"constraint,omitempty"`
+}
+
+// DependencyRequirement is a version constraint an addon puts on one of its dependencies.
+type DependencyRequirement struct {
+	By         string `json:"by"`
+	Constraint string `json:"constraint"`
+}
+
+// DependencyConflict is a dependency for which no release satisfies every requirement.
+type DependencyConflict struct {
+	Name         string                  `json:"name"`
+	Requirements []DependencyRequirement `json:"requirements"`
+	Message      string                  `json:"message"`
 }
 
 type DependencyResolutionResult struct {
-	Dependencies []DependencyInfo `json:"dependencies"`
-	Errors       []string         `json:"errors"`
+	Dependencies []DependencyInfo     `json:"dependencies"`
+	Errors       []string             `json:"errors"`
+	Conflicts    []DependencyConflict `json:"conflicts"`
+}
+
+// DownloadProgress is emitted to the frontend while an archive downloads. Total is -1 when unknown.
+type DownloadProgress struct {
+	Name       string `json:"name"`
+	Downloaded int64  `json:"downloaded"`
+	Total      int64  `json:"total"`
 }
 
 type AddonInstallStatus struct {
@@ -50,4 +80,60 @@ type InstallWithDependenciesResult struct {
 	DependencyWarnings []string             `json:"dependencyWarnings"`
 	Dependencies       []AddonInstallStatus `json:"dependencies"`
 	MainAddon          AddonInstallStatus   `json:"mainAddon"`
+	// ConflictingAddons are installed addons that must be uninstalled before the addon or one of its
+	// dependencies can be installed
+	ConflictingAddons []string `json:"conflictingAddons"`
+	// OverwrittenPaths are preserved paths of an updated addon that the new release shipped its own copy
+	// of, replaced by the installed copy
+	OverwrittenPaths []string `json:"overwrittenPaths"`
+}
+
+// SubscriptionSyncReport compares the addons subscribed to on the account with the managed addons installed locally.
+type SubscriptionSyncReport struct {
+	// Missing are subscribed addons that are not installed
+	Missing []AddonManifest `json:"missing"`
+	// Unsubscribed are managed addons that are installed but not subscribed to
+	Unsubscribed []string `json:"unsubscribed"`
+}
+
+// Outcomes of one addon in an UpdateAll run.
+const (
+	UpdateStatusUpdated    = "updated"
+	UpdateStatusSkipped    = "skipped"
+	UpdateStatusFailed     = "failed"
+	UpdateStatusRolledBack = "rolled_back"
+)
+
+// AddonUpdateResult is the outcome of updating one addon as part of UpdateAll.
+type AddonUpdateResult struct {
+	Name        string `json:"name"`
+	Alias       string `json:"alias"`
+	Status      string `json:"status"`
+	FromVersion string `json:"fromVersion"`
+	ToVersion   string `json:"toVersion"`
+	// Reason explains a skipped addon
+	Reason string `json:"reason,omitempty"`
+	Error  string `json:"error,omitempty"`
+	// Dependencies are the dependencies the new release needed that were installed before it
+	Dependencies []AddonInstallStatus `json:"dependencies"`
+	// OverwrittenPaths are preserved paths the new release shipped its own copy of, replaced by the
+	// installed copy
+	OverwrittenPaths []string `json:"overwrittenPaths"`
+}
+
+// UpdateAllReport lists every managed addon that had an update available or was held back, in name order.
+type UpdateAllReport struct {
+	Results []AddonUpdateResult `json:"results"`
+}
+
+// UninstallResult is the outcome of uninstalling an addon. When Success is false and Dependents is not
+// empty, the uninstall was refused because the listed installed addons depend on the addon.
+type UninstallResult struct {
+	Success    bool     `json:"success"`
+	Dependents []string `json:"dependents"`
+	// Removed are the addons that were uninstalled, including dependents removed in cascade mode
+	Removed []string `json:"removed"`
+	// Unmanaged are the addons that were kept installed but are no longer managed
+	Unmanaged []string `json:"unmanaged"`
+	Error     string   `json:"error,omitempty"`
 }
@@ -0,0 +1,150 @@
+// Package signature verifies minisign style ed25519 detached signatures against pinned publisher keys.
+// It has no dependencies on the rest of the application and works fully offline.
+package signature
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+var (
+	// ErrUnsigned is returned when a payload has no signature.
+	ErrUnsigned = errors.New("payload is not signed")
+	// ErrUnknownKey is returned when a payload is signed by a key that is not trusted.
+	ErrUnknownKey = errors.New("payload is signed by an untrusted key")
+	// ErrInvalidSignature is returned when a signature by a trusted key does not match the payload.
+	ErrInvalidSignature = errors.New("signature verification failed")
+)
+
+const (
+	algorithmEd = "Ed" // signature over the message itself
+	algorithmED = "ED" // signature over the BLAKE2b-512 hash of the message
+
+	trustedCommentPrefix   = "trusted comment: "
+	untrustedCommentPrefix = "untrusted comment: "
+)
+
+type KeyID [8]byte
+
+// String formats the ID the way minisign displays it, as a little endian integer.
+func (id KeyID) String() string {
+	return fmt.Sprintf("%016X", binary.LittleEndian.Uint64(id[:]))
+}
+
+type PublicKey struct {
+	ID  KeyID
+	Key ed25519.PublicKey
+}
+
+// ParsePublicKey parses a minisign public key, either the contents of a .pub file or just its base64 line.
+func ParsePublicKey(text string) (PublicKey, error) {
+	line := lastNonCommentLine(text)
+	raw, err := base64.StdEncoding.DecodeString(line)
+	if err != nil {
+		return PublicKey{}, fmt.Errorf("invalid public key encoding: %w", err)
+	}
+	if len(raw) != 2+8+ed25519.PublicKeySize || string(raw[:2]) != algorithmEd {
+		return PublicKey{}, errors.New("invalid public key: not an ed25519 minisign key")
+	}
+
+	var key PublicKey
+	copy(key.ID[:], raw[2:10])
+	key.Key = ed25519.PublicKey(bytes.Clone(raw[10:]))
+	return key, nil
+}
+
+type Signature struct {
+	Algorithm       string
+	KeyID           KeyID
+	Signature       []byte
+	TrustedComment  string
+	GlobalSignature []byte
+}
+
+// ParseSignature parses the four line minisign signature format: an untrusted comment, the signature,
+// a trusted comment and the global signature covering the signature and trusted comment.
+func ParseSignature(text string) (Signature, error) {
+	lines := strings.Split(strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n")), "\n")
+	if len(lines) != 4 {
+		return Signature{}, errors.New("invalid signature: expected 4 lines")
+	}
+	if !strings.HasPrefix(lines[0], untrustedCommentPrefix) {
+		return Signature{}, errors.New("invalid signature: missing untrusted comment")
+	}
+	if !strings.HasPrefix(lines[2], trustedCommentPrefix) {
+		return Signature{}, errors.New("invalid signature: missing trusted comment")
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil {
+		return Signature{}, fmt.Errorf("invalid signature encoding: %w", err)
+	}
+	if len(raw) != 2+8+ed25519.SignatureSize {
+		return Signature{}, errors.New("invalid signature length")
+	}
+
+	global, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil {
+		return Signature{}, fmt.Errorf("invalid global signature encoding: %w", err)
+	}
+	if len(global) != ed25519.SignatureSize {
+		return Signature{}, errors.New("invalid global signature length")
+	}
+
+	sig := Signature{
+		Algorithm:       string(raw[:2]),
+		Signature:       raw[10:],
+		TrustedComment:  strings.TrimPrefix(lines[2], trustedCommentPrefix),
+		GlobalSignature: global,
+	}
+	copy(sig.KeyID[:], raw[2:10])
+
+	if sig.Algorithm != algorithmEd && sig.Algorithm != algorithmED {
+		return Signature{}, fmt.Errorf("unsupported signature algorithm %q", sig.Algorithm)
+	}
+
+	return sig, nil
+}
+
+// Verify checks that sig is a valid signature of message by key.
+func Verify(message []byte, sig Signature, key PublicKey) error {
+	if sig.KeyID != key.ID {
+		return fmt.Errorf("%w: signed by key %s, expected %s", ErrUnknownKey, sig.KeyID, key.ID)
+	}
+
+	signed := message
+	if sig.Algorithm == algorithmED {
+		hash := blake2b.Sum512(message)
+		signed = hash[:]
+	}
+
+	if !ed25519.Verify(key.Key, signed, sig.Signature) {
+		return ErrInvalidSignature
+	}
+
+	global := append(bytes.Clone(sig.Signature), []byte(sig.TrustedComment)...)
+	if !ed25519.Verify(key.Key, global, sig.GlobalSignature) {
+		return fmt.Errorf("%w: trusted comment was tampered with", ErrInvalidSignature)
+	}
+
+	return nil
+}
+
+func lastNonCommentLine(text string) string {
+	var line string
+	for _, l := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		l = strings.TrimSpace(l)
+		if l == "" || strings.HasPrefix(l, untrustedCommentPrefix) {
+			continue
+		}
+		line = l
+	}
+	return line
+}
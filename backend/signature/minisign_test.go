@@ -0,0 +1,179 @@
+package signature
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// Vectors produced by the minisign command line tool, signing the message "test" with both the legacy
+// and the prehashed algorithm.
+const (
+	testPublicKey = "RWQf6LRCGA9i53mlYecO4IzT51TGPpvWucNSCh1CBM0QTaLn73Y7GFO3"
+	testMessage   = "test"
+
+	testSignatureEd = "untrusted comment: signature from minisign secret key\n" +
+		"RWQf6LRCGA9i59SLOFxz6NxvASXDJeRtuZykwQepbDEGt87ig1BNpWaVWuNrm73YiIiJbq71Wi+dP9eKL8OC351vwIasSSbXxwA=\n" +
+		"trusted comment: timestamp:1635442742\tfile:test\n" +
+		"0YteLgV960ia80vnA/fHbvkyjl/IoP/HNOCaZfrF0CdhAlp7ok+Tpkya+VpWPX5C/Is3q8a/kEDSY7fBmmgJCg==\n"
+
+	testSignatureED = "untrusted comment: signature from minisign secret key\n" +
+		"RUQf6LRCGA9i559r3g7V1qNyJDApGip8MfqcadIgT9CuhV3EMhHoN1mGTkUidF/z7SrlQgXdy8ofjb7bNJJylDOocrCo8KLzZwo=\n" +
+		"trusted comment: timestamp:1635443258\tfile:test\thashed\n" +
+		"/cj37GK60vryibFn+ftOgbCvW9NKhKYgjVpFFQUcWPAnjO23wrvVDTt7cloNC06maoBli9q6qwZDXXoaxweICQ==\n"
+)
+
+func TestParsePublicKey(t *testing.T) {
+	key, err := ParsePublicKey("untrusted comment: minisign public key E7620F1842B4E81F\n" + testPublicKey + "\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := key.ID.String(); got != "E7620F1842B4E81F" {
+		t.Errorf("key ID = %s, want E7620F1842B4E81F", got)
+	}
+
+	bare, err := ParsePublicKey(testPublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bare.ID != key.ID || !bare.Key.Equal(key.Key) {
+		t.Error("bare base64 key differs from the .pub file")
+	}
+}
+
+func TestParsePublicKeyMalformed(t *testing.T) {
+	raw, _ := base64.StdEncoding.DecodeString(testPublicKey)
+	otherAlgorithm := append([]byte("ED"), raw[2:]...)
+
+	tests := map[string]string{
+		"empty":           "",
+		"not base64":      "RWQ!not-base64!",
+		"truncated":       base64.StdEncoding.EncodeToString(raw[:20]),
+		"wrong algorithm": base64.StdEncoding.EncodeToString(otherAlgorithm),
+	}
+	for name, text := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParsePublicKey(text); err == nil {
+				t.Errorf("ParsePublicKey(%q) succeeded", text)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	key, err := ParsePublicKey(testPublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		sig       string
+		algorithm string
+	}{
+		"legacy":    {testSignatureEd, algorithmEd},
+		"prehashed": {testSignatureED, algorithmED},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			sig, err := ParseSignature(tt.sig)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sig.Algorithm != tt.algorithm {
+				t.Errorf("algorithm = %q, want %q", sig.Algorithm, tt.algorithm)
+			}
+			if err := Verify([]byte(testMessage), sig, key); err != nil {
+				t.Errorf("Verify() = %v", err)
+			}
+		})
+	}
+}
+
+func TestVerifyTampered(t *testing.T) {
+	key, err := ParsePublicKey(testPublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, text := range []string{testSignatureEd, testSignatureED} {
+		sig, err := ParseSignature(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := Verify([]byte("tesT"), sig, key); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("Verify() of a tampered payload = %v, want ErrInvalidSignature", err)
+		}
+
+		sig.TrustedComment = "timestamp:0\tfile:other"
+		if err := Verify([]byte(testMessage), sig, key); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("Verify() with a tampered trusted comment = %v, want ErrInvalidSignature", err)
+		}
+	}
+}
+
+func TestVerifyOtherKey(t *testing.T) {
+	sig, err := ParseSignature(testSignatureEd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := ParsePublicKey(newTestPublicKey(t, KeyID{1, 2, 3, 4, 5, 6, 7, 8}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify([]byte(testMessage), sig, other); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Verify() with another key = %v, want ErrUnknownKey", err)
+	}
+
+	// A key reusing the signer's ID must still not verify the signature
+	impostor, err := ParsePublicKey(newTestPublicKey(t, sig.KeyID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify([]byte(testMessage), sig, impostor); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify() with a different key of the same ID = %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestParseSignatureMalformed(t *testing.T) {
+	lines := strings.Split(strings.TrimSpace(testSignatureEd), "\n")
+	raw, _ := base64.StdEncoding.DecodeString(lines[1])
+	otherAlgorithm := base64.StdEncoding.EncodeToString(append([]byte("XX"), raw[2:]...))
+	with := func(i int, line string) string {
+		l := append([]string{}, lines...)
+		l[i] = line
+		return strings.Join(l, "\n")
+	}
+
+	tests := map[string]string{
+		"empty":                     "",
+		"three lines":               strings.Join(lines[:3], "\n"),
+		"missing untrusted comment": with(0, "signature from minisign secret key"),
+		"missing trusted comment":   with(2, "timestamp:1635442742"),
+		"signature not base64":      with(1, "RWQ!not-base64!"),
+		"signature truncated":       with(1, base64.StdEncoding.EncodeToString(raw[:40])),
+		"unsupported algorithm":     with(1, otherAlgorithm),
+		"global signature short":    with(3, base64.StdEncoding.EncodeToString([]byte("short"))),
+	}
+	for name, text := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseSignature(text); err == nil {
+				t.Errorf("ParseSignature(%q) succeeded", text)
+			}
+		})
+	}
+}
+
+// newTestPublicKey returns a freshly generated minisign public key with the given ID.
+func newTestPublicKey(t *testing.T, id KeyID) string {
+	t.Helper()
+	pub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := append(append([]byte(algorithmEd), id[:]...), pub...)
+	return base64.StdEncoding.EncodeToString(raw)
+}
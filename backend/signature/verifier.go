@@ -0,0 +1,76 @@
+package signature
+
+import (
+	"errors"
+	"os"
+)
+
+// EmbeddedKeys are the publisher keys built into the binary, in minisign public key format.
+// Keys configured per registry are trusted in addition to these.
+var EmbeddedKeys = []string{}
+
+// Verifier checks payloads against a set of trusted keys. In strict mode unsigned payloads and payloads
+// signed by unknown keys are refused, otherwise they are accepted unverified. A signature by a trusted
+// key that doesn't match its payload is always refused.
+type Verifier struct {
+	Keys   []PublicKey
+	Strict bool
+}
+
+// NewVerifier parses the given public keys. Keys that fail to parse are skipped and reported in the returned error.
+func NewVerifier(keys []string, strict bool) (*Verifier, error) {
+	v := &Verifier{Strict: strict}
+	var errs []error
+	for _, text := range keys {
+		key, err := ParsePublicKey(text)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		v.Keys = append(v.Keys, key)
+	}
+	return v, errors.Join(errs...)
+}
+
+// Verify checks message against the minisign signature sigText. It returns true when the signature was
+// verified against a trusted key and false with a nil error when an unverified payload was accepted.
+func (v *Verifier) Verify(message []byte, sigText string) (bool, error) {
+	if sigText == "" {
+		if v.Strict {
+			return false, ErrUnsigned
+		}
+		return false, nil
+	}
+
+	sig, err := ParseSignature(sigText)
+	if err != nil {
+		return false, err
+	}
+
+	for _, key := range v.Keys {
+		if key.ID == sig.KeyID {
+			if err := Verify(message, sig, key); err != nil {
+				return false, err
+			}
+			return true, nil
+		}
+	}
+
+	if v.Strict {
+		return false, ErrUnknownKey
+	}
+	return false, nil
+}
+
+// VerifyFile is Verify for the contents of the file at path.
+func (v *Verifier) VerifyFile(path string, sigText string) (bool, error) {
+	if sigText == "" && !v.Strict {
+		return false, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	return v.Verify(data, sigText)
+}
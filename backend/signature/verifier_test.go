@@ -0,0 +1,88 @@
+package signature
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewVerifierSkipsInvalidKeys(t *testing.T) {
+	v, err := NewVerifier([]string{"not a key", testPublicKey}, true)
+	if err == nil {
+		t.Error("NewVerifier() reported no error for an invalid key")
+	}
+	if len(v.Keys) != 1 {
+		t.Fatalf("len(Keys) = %d, want 1", len(v.Keys))
+	}
+}
+
+func TestVerifierVerify(t *testing.T) {
+	unknownKey := newTestPublicKey(t, KeyID{1, 2, 3, 4, 5, 6, 7, 8})
+
+	tests := map[string]struct {
+		keys         []string
+		strict       bool
+		message      string
+		sig          string
+		wantVerified bool
+		wantErr      error
+		malformed    bool
+	}{
+		"legacy":                 {keys: []string{testPublicKey}, message: testMessage, sig: testSignatureEd, wantVerified: true},
+		"prehashed":              {keys: []string{testPublicKey}, message: testMessage, sig: testSignatureED, wantVerified: true},
+		"legacy strict":          {keys: []string{testPublicKey}, strict: true, message: testMessage, sig: testSignatureEd, wantVerified: true},
+		"tampered":               {keys: []string{testPublicKey}, message: "tesT", sig: testSignatureEd, wantErr: ErrInvalidSignature},
+		"tampered strict":        {keys: []string{testPublicKey}, strict: true, message: "tesT", sig: testSignatureED, wantErr: ErrInvalidSignature},
+		"unknown key":            {keys: []string{unknownKey}, message: testMessage, sig: testSignatureEd},
+		"unknown key strict":     {keys: []string{unknownKey}, strict: true, message: testMessage, sig: testSignatureEd, wantErr: ErrUnknownKey},
+		"no keys strict":         {strict: true, message: testMessage, sig: testSignatureEd, wantErr: ErrUnknownKey},
+		"unsigned":               {keys: []string{testPublicKey}, message: testMessage},
+		"unsigned strict":        {keys: []string{testPublicKey}, strict: true, message: testMessage, wantErr: ErrUnsigned},
+		"malformed signature":    {keys: []string{testPublicKey}, message: testMessage, sig: "untrusted comment: x\nRWQ=\n", malformed: true},
+		"tampered unknown key":   {keys: []string{unknownKey}, message: "tesT", sig: testSignatureEd},
+		"tampered multiple keys": {keys: []string{unknownKey, testPublicKey}, message: "tesT", sig: testSignatureEd, wantErr: ErrInvalidSignature},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			v, err := NewVerifier(tt.keys, tt.strict)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			verified, err := v.Verify([]byte(tt.message), tt.sig)
+			switch {
+			case tt.malformed:
+				if err == nil {
+					t.Error("Verify() of a malformed signature succeeded")
+				}
+			case !errors.Is(err, tt.wantErr):
+				t.Errorf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+			if verified != tt.wantVerified {
+				t.Errorf("Verify() verified = %v, want %v", verified, tt.wantVerified)
+			}
+		})
+	}
+}
+
+func TestVerifierVerifyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test")
+	if err := os.WriteFile(path, []byte(testMessage), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := NewVerifier([]string{testPublicKey}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verified, err := v.VerifyFile(path, testSignatureED)
+	if err != nil || !verified {
+		t.Errorf("VerifyFile() = %v, %v, want true, nil", verified, err)
+	}
+
+	if _, err := v.VerifyFile(path, ""); !errors.Is(err, ErrUnsigned) {
+		t.Errorf("VerifyFile() of an unsigned file = %v, want ErrUnsigned", err)
+	}
+}
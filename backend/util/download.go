@@ -2,39 +2,205 @@ package util
 
 import (
 	"ClassicAddonManager/backend/auth"
+	"ClassicAddonManager/backend/httpclient"
 	"ClassicAddonManager/backend/logger"
 	"ClassicAddonManager/backend/shared"
+	"context"
+	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
-func DownloadFile(url string, path string) error {
-	// Make a get request containing a token if there is one.
-	req, err := http.NewRequest("GET", url, nil)
+// DefaultMaxDownloadSize is the largest file DownloadFile accepts unless told otherwise.
+const DefaultMaxDownloadSize int64 = 256 << 20
+
+// progressInterval throttles how often progress callbacks fire.
+const progressInterval = 100 * time.Millisecond
+
+// ProgressFunc receives the number of bytes downloaded so far and the total size, or -1 when unknown.
+type ProgressFunc func(downloaded int64, total int64)
+
+type DownloadOptions struct {
+	// MaxSize is the largest accepted file in bytes, DefaultMaxDownloadSize when zero.
+	MaxSize    int64
+	OnProgress ProgressFunc
+}
+
+// DownloadFile downloads url to path. The body is streamed into path+".part" and renamed into place once
+// complete, so path only ever holds a complete file. An interrupted download is resumed with an HTTP Range
+// request when the server still serves the same file.
+func DownloadFile(ctx context.Context, url string, path string, opts DownloadOptions) error {
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = DefaultMaxDownloadSize
+	}
+
+	partPath := path + ".part"
+	validatorPath := partPath + ".validator"
+
+	// Only resume when we know which version of the file the partial download belongs to
+	var offset int64
+	validator, _ := os.ReadFile(validatorPath)
+	if info, err := os.Stat(partPath); err == nil && len(validator) > 0 {
+		offset = info.Size()
+	}
+
+	resp, err := requestDownload(ctx, url, offset, string(validator))
 	if err != nil {
 		return err
 	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		if start, ok := contentRangeStart(resp.Header.Get("Content-Range")); !ok || start != offset {
+			return fmt.Errorf("download %s failed: unexpected Content-Range %q", url, resp.Header.Get("Content-Range"))
+		}
+		logger.Info("Resuming download of " + path + " at " + strconv.FormatInt(offset, 10) + " bytes")
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// The partial file doesn't match what the server has, start over
+		resp.Body.Close()
+		_ = os.Remove(partPath)
+		_ = os.Remove(validatorPath)
+		return DownloadFile(ctx, url, path, opts)
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+		// The server sent the whole file, either because we didn't ask for a range or the file changed
+		offset = 0
+	default:
+		return fmt.Errorf("download %s failed: status %d", url, resp.StatusCode)
+	}
+
+	var total int64 = -1
+	if resp.ContentLength >= 0 {
+		total = offset + resp.ContentLength
+		if total > opts.MaxSize {
+			return fmt.Errorf("download %s failed: size %d exceeds the limit of %d bytes", url, total, opts.MaxSize)
+		}
+	}
+
+	if v := responseValidator(resp); v != "" {
+		if err := os.WriteFile(validatorPath, []byte(v), 0644); err != nil {
+			return err
+		}
+	} else {
+		_ = os.Remove(validatorPath)
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if offset > 0 {
+		flags = os.O_WRONLY | os.O_APPEND
+	}
+	out, err := os.OpenFile(partPath, flags, 0644)
+	if err != nil {
+		return err
+	}
+
+	pw := &progressWriter{
+		w:          out,
+		downloaded: offset,
+		total:      total,
+		onProgress: opts.OnProgress,
+	}
+	// Read one byte past the limit so an oversized body without Content-Length is detected
+	_, copyErr := io.Copy(pw, io.LimitReader(resp.Body, opts.MaxSize-offset+1))
+	closeErr := out.Close()
+	if copyErr != nil {
+		// Keep the partial file so the next attempt can resume
+		return copyErr
+	}
+	if closeErr != nil {
+		return closeErr
+	}
+
+	if pw.downloaded > opts.MaxSize {
+		_ = os.Remove(partPath)
+		_ = os.Remove(validatorPath)
+		return fmt.Errorf("download %s failed: file exceeds the limit of %d bytes", url, opts.MaxSize)
+	}
+	if total >= 0 && pw.downloaded != total {
+		return fmt.Errorf("download %s failed: received %d of %d bytes", url, pw.downloaded, total)
+	}
+	pw.report(true)
+
+	if err := os.Rename(partPath, path); err != nil {
+		return err
+	}
+	_ = os.Remove(validatorPath)
+
+	logger.Info("Downloaded " + path)
+	return nil
+}
+
+func requestDownload(ctx context.Context, url string, offset int64, validator string) (*http.Response, error) {
+	// Make a get request containing a token if there is one.
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
 	// Set the authorization header if the token is set
 	if token := auth.GetToken(); token != "" {
 		req.Header.Set("X-Token", token)
 	}
 	req.Header.Set("X-Client", "Classic Addon Manager v"+shared.Version)
-	client := &http.Client{}
-	// Send the request
-	resp, err := client.Do(req)
-	if err != nil {
-		return err
+	if offset > 0 {
+		req.Header.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+		// Makes the server send the full file instead if it changed since the partial download
+		req.Header.Set("If-Range", validator)
 	}
-	defer resp.Body.Close()
 
-	out, err := os.Create(path)
+	return httpclient.Download(req)
+}
+
+// responseValidator returns the strong ETag or Last-Modified date that identifies the served file.
+func responseValidator(resp *http.Response) string {
+	if etag := resp.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return resp.Header.Get("Last-Modified")
+}
+
+// contentRangeStart parses the first byte position of a "bytes start-end/size" Content-Range header.
+func contentRangeStart(header string) (int64, bool) {
+	rest, ok := strings.CutPrefix(header, "bytes ")
+	if !ok {
+		return 0, false
+	}
+	start, _, ok := strings.Cut(rest, "-")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(start, 10, 64)
 	if err != nil {
-		return err
+		return 0, false
 	}
-	defer out.Close()
+	return n, true
+}
+
+type progressWriter struct {
+	w          io.Writer
+	downloaded int64
+	total      int64
+	onProgress ProgressFunc
+	lastReport time.Time
+}
 
-	_, err = io.Copy(out, resp.Body)
-	logger.Info("Downloaded" + path)
-	return err
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.downloaded += int64(n)
+	p.report(false)
+	return n, err
+}
+
+func (p *progressWriter) report(final bool) {
+	if p.onProgress == nil {
+		return
+	}
+	if !final && time.Since(p.lastReport) < progressInterval {
+		return
+	}
+	p.lastReport = time.Now()
+	p.onProgress(p.downloaded, p.total)
 }
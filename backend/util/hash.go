@@ -0,0 +1,124 @@
+package util
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// HashFile returns the hex encoded SHA-256 of the file at path.
+func HashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// HashDir returns the SHA-256 of every file below root keyed by its slash separated path relative to root.
+// Files for which skip returns true are left out, and directories for which it does are not descended into.
+func HashDir(root string, skip func(rel string) bool) (map[string]string, error) {
+	hashes := make(map[string]string)
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			if rel != "." && skip != nil && skip(rel) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !d.Type().IsRegular() || (skip != nil && skip(rel)) {
+			return nil
+		}
+
+		hash, err := HashFile(path)
+		if err != nil {
+			return err
+		}
+		hashes[rel] = hash
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return hashes, nil
+}
+
+// HashZipRelease returns the SHA-256 of every file in the release archive at path keyed by its slash
+// separated path below the archive's root folder, like HashDir of the extracted release would. Paths for
+// which skip returns true, or that lie below one that does, are left out.
+func HashZipRelease(path string, skip func(rel string) bool) (map[string]string, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+
+	hashes := make(map[string]string)
+	for _, f := range archive.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		_, rel, ok := strings.Cut(strings.TrimPrefix(f.Name, "/"), "/")
+		if !ok || rel == "" || (skip != nil && skipsAncestor(rel, skip)) {
+			continue
+		}
+
+		hash, err := hashZipFile(f)
+		if err != nil {
+			return nil, err
+		}
+		hashes[rel] = hash
+	}
+
+	return hashes, nil
+}
+
+// skipsAncestor reports whether skip returns true for rel or one of the folders containing it.
+func skipsAncestor(rel string, skip func(rel string) bool) bool {
+	for p := rel; p != "." && p != ""; p = path.Dir(p) {
+		if skip(p) {
+			return true
+		}
+	}
+	return false
+}
+
+func hashZipFile(f *zip.File) (string, error) {
+	r, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
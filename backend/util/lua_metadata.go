@@ -0,0 +1,349 @@
+package util
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// LuaAddonInfo is the table an addon's main.lua declares to describe itself, as in
+// local my_addon = { name = "My Addon", author = "Me", version = "1.0", desc = "Does things" }.
+type LuaAddonInfo struct {
+	Name        string
+	Author      string
+	Version     string
+	Description string
+}
+
+// luaToken is a token of Lua source. Strings hold their decoded value.
+type luaToken struct {
+	kind  luaTokenKind
+	value string
+}
+
+type luaTokenKind int
+
+const (
+	luaName luaTokenKind = iota
+	luaString
+	luaNumber
+	luaSymbol
+)
+
+// ParseLuaAddonInfo finds the addon info table in the source of a main.lua without running it. Only fields
+// whose value is a string or number literal are read, and a table only counts when it has a name and at
+// least an author, version or description, so settings or window tables with just a name are passed over.
+// A table assigned to a local outside any other table, as in the usual local my_addon = { ... }, is preferred
+// over one found elsewhere. ok is false when no such table is found.
+func ParseLuaAddonInfo(src string) (info LuaAddonInfo, ok bool) {
+	tokens := tokenizeLua(src)
+
+	var fallback LuaAddonInfo
+	found := false
+	depth := 0
+	for i, t := range tokens {
+		if t.kind != luaSymbol || (t.value != "{" && t.value != "}") {
+			continue
+		}
+		if t.value == "}" {
+			depth = max(depth-1, 0)
+			continue
+		}
+
+		topLevelLocal := depth == 0 && isLuaLocalAssignment(tokens, i)
+		depth++
+
+		candidate, ok := luaAddonInfo(luaTableLiterals(tokens[i+1:]))
+		switch {
+		case !ok:
+		case topLevelLocal:
+			return candidate, true
+		case !found:
+			fallback, found = candidate, true
+		}
+	}
+
+	return fallback, found
+}
+
+// luaAddonInfo reads the addon info from the literal fields of a table, ok is false when they don't describe an addon.
+func luaAddonInfo(fields map[string]string) (LuaAddonInfo, bool) {
+	info := LuaAddonInfo{
+		Name:        fields["name"],
+		Author:      fields["author"],
+		Version:     fields["version"],
+		Description: fields["desc"],
+	}
+	if info.Description == "" {
+		info.Description = fields["description"]
+	}
+
+	if info.Name == "" || (info.Author == "" && info.Version == "" && info.Description == "") {
+		return LuaAddonInfo{}, false
+	}
+	return info, true
+}
+
+// isLuaLocalAssignment reports whether the table constructor opening at tokens[i] is assigned in local name = {.
+func isLuaLocalAssignment(tokens []luaToken, i int) bool {
+	return i >= 3 && isLuaSymbol(tokens[i-1], "=") && tokens[i-2].kind == luaName &&
+		tokens[i-3].kind == luaName && tokens[i-3].value == "local"
+}
+
+// luaTableLiterals returns the top level fields of the table constructor starting at tokens, just after its
+// opening brace, that are assigned a literal.
+func luaTableLiterals(tokens []luaToken) map[string]string {
+	fields := make(map[string]string)
+
+	depth := 0
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		if t.kind == luaSymbol {
+			switch t.value {
+			case "{", "(", "[":
+				// [ "key" ] = value is a field with a string key
+				if t.value == "[" && depth == 0 && i+4 < len(tokens) && tokens[i+1].kind == luaString &&
+					isLuaSymbol(tokens[i+2], "]") && isLuaSymbol(tokens[i+3], "=") {
+					if key, value, ok := luaLiteralField(tokens[i+1].value, tokens[i+4:]); ok {
+						fields[key] = value
+					}
+					i += 4
+					continue
+				}
+				depth++
+			case "}", ")", "]":
+				if depth == 0 {
+					return fields
+				}
+				depth--
+			}
+			continue
+		}
+
+		if depth == 0 && t.kind == luaName && i+2 < len(tokens) && isLuaSymbol(tokens[i+1], "=") &&
+			(i == 0 || isLuaSymbol(tokens[i-1], ",") || isLuaSymbol(tokens[i-1], ";")) {
+			if key, value, ok := luaLiteralField(t.value, tokens[i+2:]); ok {
+				fields[key] = value
+			}
+			i += 2
+		}
+	}
+
+	return fields
+}
+
+// luaLiteralField returns key and the literal that starts rest when nothing else is part of the value.
+func luaLiteralField(key string, rest []luaToken) (string, string, bool) {
+	value := rest[0]
+	if value.kind != luaString && value.kind != luaNumber {
+		return "", "", false
+	}
+	if len(rest) > 1 && !isLuaSymbol(rest[1], ",") && !isLuaSymbol(rest[1], ";") && !isLuaSymbol(rest[1], "}") {
+		return "", "", false
+	}
+	return key, value.value, true
+}
+
+func isLuaSymbol(t luaToken, symbol string) bool {
+	return t.kind == luaSymbol && t.value == symbol
+}
+
+// tokenizeLua splits Lua source into names, literals and symbols, dropping comments. Malformed input ends
+// the token stream early rather than failing.
+func tokenizeLua(src string) []luaToken {
+	var tokens []luaToken
+
+	for i := 0; i < len(src); {
+		c := src[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f' || c == '\v':
+			i++
+
+		case strings.HasPrefix(src[i:], "--"):
+			i += 2
+			if level, ok := luaLongBracketLevel(src[i:]); ok {
+				end := luaLongBracketEnd(src, i+level+2, level)
+				if end < 0 {
+					return tokens
+				}
+				i = end
+			} else if nl := strings.IndexByte(src[i:], '\n'); nl >= 0 {
+				i += nl + 1
+			} else {
+				i = len(src)
+			}
+
+		case c == '"' || c == '\'':
+			value, end, ok := readLuaQuotedString(src, i)
+			if !ok {
+				return tokens
+			}
+			tokens = append(tokens, luaToken{kind: luaString, value: value})
+			i = end
+
+		case c == '[':
+			if level, ok := luaLongBracketLevel(src[i:]); ok {
+				start := i + level + 2
+				end := luaLongBracketEnd(src, start, level)
+				if end < 0 {
+					return tokens
+				}
+				value := src[start : end-level-2]
+				// A newline right after the opening bracket is not part of the string
+				value = strings.TrimPrefix(strings.TrimPrefix(value, "\r"), "\n")
+				tokens = append(tokens, luaToken{kind: luaString, value: value})
+				i = end
+			} else {
+				tokens = append(tokens, luaToken{kind: luaSymbol, value: "["})
+				i++
+			}
+
+		case isLuaNameStart(c):
+			start := i
+			for i < len(src) && (isLuaNameStart(src[i]) || (src[i] >= '0' && src[i] <= '9')) {
+				i++
+			}
+			tokens = append(tokens, luaToken{kind: luaName, value: src[start:i]})
+
+		case c >= '0' && c <= '9' || (c == '.' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9'):
+			start := i
+			for i < len(src) && (isLuaNameStart(src[i]) || (src[i] >= '0' && src[i] <= '9') || src[i] == '.' ||
+				((src[i] == '-' || src[i] == '+') && (src[i-1] == 'e' || src[i-1] == 'E' || src[i-1] == 'p' || src[i-1] == 'P'))) {
+				i++
+			}
+			tokens = append(tokens, luaToken{kind: luaNumber, value: src[start:i]})
+
+		default:
+			symbol := string(c)
+			for _, s := range []string{"...", "..", "==", "~=", "<=", ">=", "::", "//", "<<", ">>"} {
+				if strings.HasPrefix(src[i:], s) {
+					symbol = s
+					break
+				}
+			}
+			tokens = append(tokens, luaToken{kind: luaSymbol, value: symbol})
+			i += len(symbol)
+		}
+	}
+
+	return tokens
+}
+
+func isLuaNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// luaLongBracketLevel reports whether s starts with an opening long bracket such as [[ or [==[, and its level.
+func luaLongBracketLevel(s string) (int, bool) {
+	if !strings.HasPrefix(s, "[") {
+		return 0, false
+	}
+	level := 0
+	for level+1 < len(s) && s[level+1] == '=' {
+		level++
+	}
+	if level+1 < len(s) && s[level+1] == '[' {
+		return level, true
+	}
+	return 0, false
+}
+
+// luaLongBracketEnd returns the index just past the closing long bracket of the given level, or -1.
+func luaLongBracketEnd(src string, from int, level int) int {
+	closing := "]" + strings.Repeat("=", level) + "]"
+	idx := strings.Index(src[from:], closing)
+	if idx < 0 {
+		return -1
+	}
+	return from + idx + len(closing)
+}
+
+// readLuaQuotedString decodes the quoted string starting at src[start] and returns the index past its
+// closing quote.
+func readLuaQuotedString(src string, start int) (string, int, bool) {
+	quote := src[start]
+	var b strings.Builder
+
+	for i := start + 1; i < len(src); {
+		c := src[i]
+		switch {
+		case c == quote:
+			return b.String(), i + 1, true
+		case c == '\n':
+			return "", 0, false
+		case c != '\\':
+			b.WriteByte(c)
+			i++
+			continue
+		}
+
+		i++
+		if i >= len(src) {
+			return "", 0, false
+		}
+		e := src[i]
+		i++
+		switch e {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case 'a':
+			b.WriteByte('\a')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'v':
+			b.WriteByte('\v')
+		case '\n':
+			b.WriteByte('\n')
+		case 'z':
+			for i < len(src) && strings.IndexByte(" \t\r\n\f\v", src[i]) >= 0 {
+				i++
+			}
+		case 'x':
+			if i+2 > len(src) {
+				return "", 0, false
+			}
+			n, err := strconv.ParseUint(src[i:i+2], 16, 8)
+			if err != nil {
+				return "", 0, false
+			}
+			b.WriteByte(byte(n))
+			i += 2
+		case 'u':
+			end := strings.IndexByte(src[i:], '}')
+			if !strings.HasPrefix(src[i:], "{") || end < 0 {
+				return "", 0, false
+			}
+			n, err := strconv.ParseUint(src[i+1:i+end], 16, 32)
+			if err != nil || n > utf8.MaxRune {
+				return "", 0, false
+			}
+			b.WriteRune(rune(n))
+			i += end + 1
+		default:
+			if e >= '0' && e <= '9' {
+				digits := 1
+				for digits < 3 && i < len(src) && src[i] >= '0' && src[i] <= '9' {
+					i++
+					digits++
+				}
+				n, err := strconv.Atoi(src[i-digits : i])
+				if err != nil || n > 255 {
+					return "", 0, false
+				}
+				b.WriteByte(byte(n))
+			} else {
+				// \\, \", \' and anything unknown stand for the character itself
+				b.WriteByte(e)
+			}
+		}
+	}
+
+	return "", 0, false
+}
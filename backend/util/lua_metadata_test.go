@@ -0,0 +1,149 @@
+package util
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParseLuaAddonInfo(t *testing.T) {
+	cam, err := os.ReadFile("../addon/cam.lua")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		src    string
+		want   LuaAddonInfo
+		wantOK bool
+	}{
+		{
+			name: "cam.lua",
+			src:  string(cam),
+			want: LuaAddonInfo{
+				Name:        "Classic Addon Manager",
+				Author:      "Sami",
+				Version:     "1.0",
+				Description: "Notifies whenever new addon updates are available!",
+			},
+			wantOK: true,
+		},
+		{
+			name:   "description and number version",
+			src:    `local a = { name = "A", version = 1.5, description = "Long form" }`,
+			want:   LuaAddonInfo{Name: "A", Version: "1.5", Description: "Long form"},
+			wantOK: true,
+		},
+		{
+			name:   "bracketed keys and semicolons",
+			src:    `local a = { ["name"] = 'A'; ['author'] = "Me"; [ [[version]] ] = "2" }`,
+			want:   LuaAddonInfo{Name: "A", Author: "Me", Version: "2"},
+			wantOK: true,
+		},
+		{
+			name: "table with only a name is skipped",
+			src: `local settings = { name = "Settings" }
+local addon = { name = "A", author = "Me" }`,
+			want:   LuaAddonInfo{Name: "A", Author: "Me"},
+			wantOK: true,
+		},
+		{
+			name: "top level local is preferred",
+			src: `api.Log:Info({ name = "Dependency", version = "9" })
+local addon = { name = "A", version = "1" }`,
+			want:   LuaAddonInfo{Name: "A", Version: "1"},
+			wantOK: true,
+		},
+		{
+			name: "first table wins without a top level local",
+			src: `local window = { child = { name = "Child", version = "0" } }
+return { name = "A", version = "1" }`,
+			want:   LuaAddonInfo{Name: "Child", Version: "0"},
+			wantOK: true,
+		},
+		{
+			name:   "table returned without a local",
+			src:    `return { name = "A", author = "Me", desc = "Returned" }`,
+			want:   LuaAddonInfo{Name: "A", Author: "Me", Description: "Returned"},
+			wantOK: true,
+		},
+		{
+			name: "fields that aren't literals are ignored",
+			src: `local addon = { name = "A", version = VERSION, author = "Me" .. "!", desc = "D" }
+local other = { name = "B", version = "2" }`,
+			want:   LuaAddonInfo{Name: "A", Description: "D"},
+			wantOK: true,
+		},
+		{
+			name: "comments",
+			src: `-- local addon = { name = "Line", version = "0" }
+--[==[
+local addon = { name = "Block", version = "0" }
+]]still in the comment]==]
+local addon = { -- name = "Inline",
+	name = "A", --[[ version = "0", ]] version = "1",
+}`,
+			want:   LuaAddonInfo{Name: "A", Version: "1"},
+			wantOK: true,
+		},
+		{
+			name: "long strings",
+			src: `local addon = { name = [[A]], desc = [==[
+First line
+with ]] inside]==], version = [[
+1.0]] }`,
+			want:   LuaAddonInfo{Name: "A", Version: "1.0", Description: "First line\nwith ]] inside"},
+			wantOK: true,
+		},
+		{
+			name:   "escape sequences",
+			src:    `local addon = { name = 'It\'s \65\066', author = "\x4d\u{e9}", desc = "tab\tquote\"\\ \z    joined", version = "1\n2" }`,
+			want:   LuaAddonInfo{Name: "It's AB", Author: "Mé", Version: "1\n2", Description: "tab\tquote\"\\ joined"},
+			wantOK: true,
+		},
+		{
+			name:   "no info table",
+			src:    `local settings = { name = "Settings" } local x = { version = "1" }`,
+			wantOK: false,
+		},
+		{
+			name:   "empty",
+			src:    "",
+			wantOK: false,
+		},
+		{
+			name:   "unterminated string",
+			src:    `local addon = { name = "A, version = "1" }`,
+			wantOK: false,
+		},
+		{
+			name:   "unterminated long string",
+			src:    `local addon = { name = "A", desc = [[never closed }`,
+			wantOK: false,
+		},
+		{
+			name:   "unterminated long comment",
+			src:    "--[[ local addon = { name = \"A\", version = \"1\" }",
+			wantOK: false,
+		},
+		{
+			name:   "unterminated table",
+			src:    `local addon = { name = "A", version = "1"`,
+			want:   LuaAddonInfo{Name: "A", Version: "1"},
+			wantOK: true,
+		},
+		{
+			name:   "invalid escape",
+			src:    `local addon = { name = "A\xZZ", version = "1" }`,
+			wantOK: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseLuaAddonInfo(tt.src)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("ParseLuaAddonInfo() = %+v, %v, want %+v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package util
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	mdHTMLComment    = regexp.MustCompile(`(?s)<!--.*?-->`)
+	mdHTMLTag        = regexp.MustCompile(`<[^>]+>`)
+	mdCodeFence      = regexp.MustCompile("^\\s*(```|~~~)")
+	mdHeading        = regexp.MustCompile(`^\s{0,3}#{1,6}\s+`)
+	mdBlockquote     = regexp.MustCompile(`^\s{0,3}>\s?`)
+	mdListItem       = regexp.MustCompile(`^(\s*)[-*+]\s+`)
+	mdHorizontalRule = regexp.MustCompile(`^\s{0,3}([-*_]\s*){3,}$`)
+	mdImage          = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	mdLink           = regexp.MustCompile(`\[([^\]]+)\]\([^)]*\)`)
+	mdBold           = regexp.MustCompile(`\*\*(.+?)\*\*|__(.+?)__`)
+	mdItalic         = regexp.MustCompile(`\*([^*\s][^*]*?)\*`)
+	mdStrike         = regexp.MustCompile(`~~(.+?)~~`)
+	mdInlineCode     = regexp.MustCompile("`([^`]+)`")
+)
+
+// MarkdownToPlainText strips Markdown and HTML markup from release notes so they can be shown
+// as plain text. List items are kept as "- " lines and links are reduced to their text.
+func MarkdownToPlainText(md string) string {
+	md = strings.ReplaceAll(md, "\r\n", "\n")
+	md = mdHTMLComment.ReplaceAllString(md, "")
+
+	var lines []string
+	inCode := false
+	blank := 0
+	for _, line := range strings.Split(md, "\n") {
+		if mdCodeFence.MatchString(line) {
+			inCode = !inCode
+			continue
+		}
+
+		if !inCode {
+			line = mdHTMLTag.ReplaceAllString(line, "")
+			if mdHorizontalRule.MatchString(line) {
+				line = ""
+			}
+			line = mdHeading.ReplaceAllString(line, "")
+			line = mdBlockquote.ReplaceAllString(line, "")
+			line = mdListItem.ReplaceAllString(line, "$1- ")
+			line = mdImage.ReplaceAllString(line, "$1")
+			line = mdLink.ReplaceAllString(line, "$1")
+			line = mdBold.ReplaceAllString(line, "$1$2")
+			line = mdItalic.ReplaceAllString(line, "$1")
+			line = mdStrike.ReplaceAllString(line, "$1")
+			line = mdInlineCode.ReplaceAllString(line, "$1")
+			line = html.UnescapeString(line)
+		}
+
+		line = strings.TrimRight(line, " \t")
+		if line == "" {
+			blank++
+			if blank > 1 {
+				continue
+			}
+		} else {
+			blank = 0
+		}
+		lines = append(lines, line)
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
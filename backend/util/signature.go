@@ -0,0 +1,18 @@
+package util
+
+import (
+	"ClassicAddonManager/backend/config"
+	"ClassicAddonManager/backend/logger"
+	"ClassicAddonManager/backend/signature"
+)
+
+// NewSignatureVerifier returns a verifier trusting the embedded publisher keys and the keys configured
+// for the current registry, refusing unsigned payloads when general.strictsignatures is set.
+func NewSignatureVerifier() *signature.Verifier {
+	keys := append(append([]string{}, signature.EmbeddedKeys...), config.GetRegistryPublicKeys(config.GetApiURL())...)
+	verifier, err := signature.NewVerifier(keys, config.GetBool("general.strictsignatures"))
+	if err != nil {
+		logger.Error("Ignoring invalid publisher keys:", err)
+	}
+	return verifier
+}
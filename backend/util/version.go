@@ -0,0 +1,225 @@
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Version is a dotted release version such as "1.2", "v2.0.1" or "1.3.0-beta.2". Any number of numeric
+// components is accepted and missing components compare as zero, so "1.2" equals "1.2.0".
+type Version struct {
+	Parts      []int
+	Prerelease string
+}
+
+// ParseVersion parses a release tag. A leading "v" and build metadata after "+" are ignored.
+func ParseVersion(s string) (Version, error) {
+	raw := s
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "v"), "V")
+	s, _, _ = strings.Cut(s, "+")
+
+	var v Version
+	s, v.Prerelease, _ = strings.Cut(s, "-")
+	if s == "" {
+		return Version{}, fmt.Errorf("invalid version %q", raw)
+	}
+
+	for _, part := range strings.Split(s, ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return Version{}, fmt.Errorf("invalid version %q", raw)
+		}
+		v.Parts = append(v.Parts, n)
+	}
+
+	return v, nil
+}
+
+// Compare returns -1, 0 or 1 when v is lower than, equal to or higher than o. A prerelease is lower than
+// the release it precedes.
+func (v Version) Compare(o Version) int {
+	for i := 0; i < max(len(v.Parts), len(o.Parts)); i++ {
+		a, b := 0, 0
+		if i < len(v.Parts) {
+			a = v.Parts[i]
+		}
+		if i < len(o.Parts) {
+			b = o.Parts[i]
+		}
+		if a != b {
+			if a < b {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	switch {
+	case v.Prerelease == o.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case o.Prerelease == "":
+		return -1
+	}
+	return comparePrerelease(v.Prerelease, o.Prerelease)
+}
+
+// comparePrerelease orders prerelease labels by their dot separated identifiers, numerically where both are numbers.
+func comparePrerelease(a string, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < min(len(as), len(bs)); i++ {
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if an != bn {
+				if an < bn {
+					return -1
+				}
+				return 1
+			}
+		case as[i] != bs[i]:
+			if as[i] < bs[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	switch {
+	case len(as) < len(bs):
+		return -1
+	case len(as) > len(bs):
+		return 1
+	}
+	return 0
+}
+
+// versionComparator is one "op version" term of a VersionConstraint.
+type versionComparator struct {
+	op      string
+	version Version
+	raw     string
+}
+
+func (c versionComparator) check(v Version) bool {
+	cmp := v.Compare(c.version)
+	switch c.op {
+	case "=", "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	}
+	return false
+}
+
+// VersionConstraint is a set of comparators that must all hold, such as ">=1.2 <2". The empty constraint
+// accepts every version.
+type VersionConstraint struct {
+	comparators []versionComparator
+}
+
+// comparatorOps is ordered so longer operators are matched before their prefixes.
+var comparatorOps = []string{">=", "<=", "==", "!=", ">", "<", "=", "^", "~"}
+
+// ParseVersionConstraint parses whitespace or comma separated comparators using =, !=, >, >=, <, <=, ^ (same major
+// version) and ~ (same minor version). A bare version means =.
+func ParseVersionConstraint(s string) (VersionConstraint, error) {
+	var c VersionConstraint
+
+	fields := strings.FieldsFunc(s, func(r rune) bool { return unicode.IsSpace(r) || r == ',' })
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+
+		op := ""
+		for _, candidate := range comparatorOps {
+			if strings.HasPrefix(field, candidate) {
+				op = candidate
+				break
+			}
+		}
+		rest := strings.TrimPrefix(field, op)
+		// Allow a space between the operator and the version, as in ">= 1.2"
+		if rest == "" && op != "" && i+1 < len(fields) {
+			i++
+			rest = fields[i]
+		}
+		if op == "" {
+			op = "="
+		}
+
+		v, err := ParseVersion(rest)
+		if err != nil {
+			return VersionConstraint{}, fmt.Errorf("invalid version constraint %q: %w", s, err)
+		}
+
+		// The upper bound of ^ and ~ is the lowest prerelease of the next version, so "^1.2" refuses "2.0.0-beta"
+		switch op {
+		case "^":
+			c.comparators = append(c.comparators,
+				versionComparator{op: ">=", version: v, raw: field},
+				versionComparator{op: "<", version: Version{Parts: []int{v.Parts[0] + 1}, Prerelease: "0"}, raw: field})
+		case "~":
+			upper := Version{Parts: []int{v.Parts[0] + 1}, Prerelease: "0"}
+			if len(v.Parts) > 1 {
+				upper = Version{Parts: []int{v.Parts[0], v.Parts[1] + 1}, Prerelease: "0"}
+			}
+			c.comparators = append(c.comparators,
+				versionComparator{op: ">=", version: v, raw: field},
+				versionComparator{op: "<", version: upper, raw: field})
+		default:
+			c.comparators = append(c.comparators, versionComparator{op: op, version: v, raw: op + rest})
+		}
+	}
+
+	return c, nil
+}
+
+// IsEmpty reports whether the constraint accepts every version.
+func (c VersionConstraint) IsEmpty() bool {
+	return len(c.comparators) == 0
+}
+
+// Check reports whether version satisfies every comparator. Versions that can't be parsed only satisfy
+// the empty constraint.
+func (c VersionConstraint) Check(version string) bool {
+	if c.IsEmpty() {
+		return true
+	}
+
+	v, err := ParseVersion(version)
+	if err != nil {
+		return false
+	}
+
+	for _, comparator := range c.comparators {
+		if !comparator.check(v) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c VersionConstraint) String() string {
+	var parts []string
+	for i, comparator := range c.comparators {
+		// ^ and ~ expand to two comparators sharing the original text
+		if i > 0 && c.comparators[i-1].raw == comparator.raw {
+			continue
+		}
+		parts = append(parts, comparator.raw)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,149 @@
+package util
+
+import "testing"
+
+func TestParseVersion(t *testing.T) {
+	tests := map[string]struct {
+		in   string
+		want string
+	}{
+		"plain":       {"1.2.3", "1.2.3"},
+		"v prefix":    {"v1.2", "1.2.0"},
+		"V prefix":    {"V2", "2.0.0"},
+		"prerelease":  {"v1.3.0-beta.2", "1.3.0-beta.2"},
+		"build":       {"1.0.0+20240101", "1.0.0"},
+		"padded":      {" 1.0 ", "1.0.0"},
+		"four digits": {"1.2.3.4", "1.2.3.4"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseVersion(tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, _ := ParseVersion(tt.want)
+			if got.Compare(want) != 0 {
+				t.Errorf("ParseVersion(%q) = %v, want %v", tt.in, got, want)
+			}
+		})
+	}
+
+	for _, in := range []string{"", "v", "1.x", "1..2", "-beta", "latest"} {
+		if _, err := ParseVersion(in); err == nil {
+			t.Errorf("ParseVersion(%q) succeeded", in)
+		}
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	// Each version is lower than the next one
+	ordered := []string{
+		"0.9",
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"1.0.1",
+		"1.2",
+		"1.10",
+		"2",
+	}
+	for i := 0; i+1 < len(ordered); i++ {
+		a, _ := ParseVersion(ordered[i])
+		b, _ := ParseVersion(ordered[i+1])
+		if a.Compare(b) != -1 || b.Compare(a) != 1 {
+			t.Errorf("expected %s < %s", ordered[i], ordered[i+1])
+		}
+	}
+
+	a, _ := ParseVersion("v1.2")
+	b, _ := ParseVersion("1.2.0")
+	if a.Compare(b) != 0 {
+		t.Error("expected v1.2 == 1.2.0")
+	}
+}
+
+func TestVersionConstraint(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		want       bool
+	}{
+		{"", "anything", true},
+		{"1.2", "v1.2.0", true},
+		{"1.2", "1.2.1", false},
+		{"=1.2", "1.2", true},
+		{"!=1.2", "1.2", false},
+		{">=1.2", "1.2", true},
+		{">=1.2", "1.1.9", false},
+		{">= 1.2", "1.3", true},
+		{">= 1.2", "1.1", false},
+		{">= 1.2, < 2", "1.9", true},
+		{">= 1.2, < 2", "2.0", false},
+		{">=1.2 <2", "2.0.0-beta", true},
+		{">=1.2\t<2", "2.1", false},
+		{">1.0", "1.0.1", true},
+		{"<=1.0", "1.0.0-rc.1", true},
+		{">=v1.2", "v1.2.0", true},
+
+		// ^ keeps the major version
+		{"^1.2", "1.2.0", true},
+		{"^1.2", "1.9.9", true},
+		{"^1.2", "1.1", false},
+		{"^1.2", "2.0.0", false},
+		{"^1.2", "2.0.0-beta", false},
+		{"^1.2", "2.0.0-0", false},
+		{"^1.2", "1.9.9-rc.1", true},
+
+		// ~ keeps the minor version, or the major version when only it is given
+		{"~1.2", "1.2.5", true},
+		{"~1.2", "1.3.0", false},
+		{"~1.2", "1.3.0-alpha", false},
+		{"~1.2.3", "1.2.2", false},
+		{"~1.2.3", "1.2.9", true},
+		{"~1", "1.9", true},
+		{"~1", "2.0", false},
+
+		{">=1.2", "not a version", false},
+	}
+	for _, tt := range tests {
+		c, err := ParseVersionConstraint(tt.constraint)
+		if err != nil {
+			t.Errorf("ParseVersionConstraint(%q) = %v", tt.constraint, err)
+			continue
+		}
+		if got := c.Check(tt.version); got != tt.want {
+			t.Errorf("%q.Check(%q) = %v, want %v", tt.constraint, tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestVersionConstraintString(t *testing.T) {
+	tests := map[string]string{
+		">= 1.2, <2":   ">=1.2 <2",
+		"^1.2":         "^1.2",
+		"~1.2 !=1.2.1": "~1.2 !=1.2.1",
+		"1.0":          "=1.0",
+	}
+	for in, want := range tests {
+		c, err := ParseVersionConstraint(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := c.String(); got != want {
+			t.Errorf("ParseVersionConstraint(%q).String() = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestParseVersionConstraintInvalid(t *testing.T) {
+	for _, in := range []string{">=", ">=abc", "^", "1.2 <x"} {
+		if _, err := ParseVersionConstraint(in); err == nil {
+			t.Errorf("ParseVersionConstraint(%q) succeeded", in)
+		}
+	}
+}
@@ -5,33 +5,35 @@ import (
 	"ClassicAddonManager/backend/file"
 	"ClassicAddonManager/backend/logger"
 	"archive/zip"
-	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
 )
 
 func ExtractAddonRelease(src string, dest string) error {
-	tmpSrc := filepath.Join(config.GetCacheDir(), src)
-	if !file.FileExists(tmpSrc) {
-		return fmt.Errorf("file %s does not exist", tmpSrc)
+	return ExtractZip(filepath.Join(config.GetCacheDir(), src), filepath.Join(config.GetCacheDir(), dest))
+}
+
+// ExtractZip extracts the archive at src into the dest directory.
+func ExtractZip(src string, dest string) error {
+	if !file.FileExists(src) {
+		return fmt.Errorf("file %s does not exist", src)
 	}
 
-	archive, err := zip.OpenReader(tmpSrc)
+	archive, err := zip.OpenReader(src)
 	if err != nil {
 		return err
 	}
 	defer archive.Close()
 
-	tmpDest := filepath.Join(config.GetCacheDir(), dest)
-
 	for _, f := range archive.File {
-		fPath := filepath.Join(tmpDest, f.Name)
+		fPath := filepath.Join(dest, f.Name)
 		logger.Info("Extracting: " + fPath)
 
-		if !strings.HasPrefix(fPath, filepath.Clean(tmpDest)+string(os.PathSeparator)) {
+		if !strings.HasPrefix(fPath, filepath.Clean(dest)+string(os.PathSeparator)) {
 			return fmt.Errorf("%s: invalid file path", fPath)
 		}
 
@@ -56,60 +58,123 @@ func ExtractAddonRelease(src string, dest string) error {
 	return nil
 }
 
-func extractFile(f *zip.File, dest string) error {
-	fileInArchive, err := f.Open()
+// ZipDir writes every file below src to a new archive at dest, stored under a top level folder named root.
+func ZipDir(src string, dest string, root string) error {
+	out, err := os.Create(dest)
 	if err != nil {
 		return err
 	}
-	defer fileInArchive.Close()
 
-	dstFile, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode())
-	if err != nil {
-		return err
+	w := zip.NewWriter(out)
+	walkErr := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(filepath.Join(root, rel))
+
+		if d.IsDir() {
+			_, err := w.Create(name + "/")
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		return addFileToZip(w, path, name)
+	})
+
+	closeErr := w.Close()
+	if err := out.Close(); err != nil && closeErr == nil {
+		closeErr = err
+	}
+	if walkErr != nil || closeErr != nil {
+		_ = os.Remove(dest)
+		if walkErr != nil {
+			return walkErr
+		}
+		return closeErr
 	}
-	defer dstFile.Close()
 
-	_, err = io.Copy(dstFile, fileInArchive)
-	return err
+	return nil
 }
 
-func MoveAddonRelease(addonName string) bool {
-	src := filepath.Join(config.GetCacheDir(), addonName)
-	entries, err := os.ReadDir(src)
+// ZipFiles writes the given files, slash separated paths relative to src, to a new archive at dest, stored
+// under a top level folder named root.
+func ZipFiles(src string, files []string, dest string, root string) error {
+	out, err := os.Create(dest)
 	if err != nil {
-		logger.Error("Error reading directory:", err)
-		return false
+		return err
 	}
 
-	var rootDir string
-
-	for _, entry := range entries {
-		if entry.IsDir() {
-			rootDir = entry.Name()
+	w := zip.NewWriter(out)
+	var addErr error
+	for _, rel := range files {
+		if addErr = addFileToZip(w, filepath.Join(src, filepath.FromSlash(rel)), root+"/"+rel); addErr != nil {
 			break
 		}
 	}
 
-	if rootDir == "" {
-		logger.Error(addonName+" - No root directory found in addon release, aborting", errors.New("no root directory found"))
-		return false
+	closeErr := w.Close()
+	if err := out.Close(); err != nil && closeErr == nil {
+		closeErr = err
 	}
-
-	if file.FileExists(filepath.Join(config.GetAddonDir(), addonName)) {
-		err = os.RemoveAll(filepath.Join(config.GetAddonDir(), addonName))
-		if err != nil {
-			logger.Error("Error removing old addon release:", err)
-			return false
+	if addErr != nil || closeErr != nil {
+		_ = os.Remove(dest)
+		if addErr != nil {
+			return addErr
 		}
+		return closeErr
 	}
 
-	err = file.MoveDir(filepath.Join(src, rootDir), filepath.Join(config.GetAddonDir(), addonName))
+	return nil
+}
+
+func addFileToZip(w *zip.Writer, path string, name string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	header.Method = zip.Deflate
+
+	dst, err := w.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(dst, f)
+	return err
+}
+
+func extractFile(f *zip.File, dest string) error {
+	fileInArchive, err := f.Open()
 	if err != nil {
-		logger.Error("Error moving addon release:", err)
-		return false
+		return err
 	}
+	defer fileInArchive.Close()
 
-	_ = os.RemoveAll(src)
+	dstFile, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode())
+	if err != nil {
+		return err
+	}
+	defer dstFile.Close()
 
-	return true
+	_, err = io.Copy(dstFile, fileInArchive)
+	return err
 }
@@ -99,10 +99,13 @@ const NoAddonsWorkingContent = () => {
       })
       return
     }
-    let i = 0
+    // Dependents are removed along with the addons they need, so some addons are already gone by their turn
+    const removed = new Set<string>()
     for (const a of installedAddons) {
-      const uninstalled = await uninstall(a)
-      if (!uninstalled) {
+      if (removed.has(a.name)) continue
+
+      const [result] = await safeCall(uninstall(a, 'cascade'))
+      if (!result?.success) {
         toast({
           title: 'Error',
           description: `Failed to uninstall addon: ${a.alias}`,
@@ -110,11 +113,11 @@ const NoAddonsWorkingContent = () => {
         })
         return
       }
-      i++
+      result.removed.forEach(name => removed.add(name))
     }
     toast({
       title: 'Success',
-      description: `${i} addons were uninstalled successfully. Please restart the game.`,
+      description: `${removed.size} addons were uninstalled successfully. Please restart the game.`,
       icon: CheckIcon,
     })
 
@@ -32,7 +32,11 @@ export const DependenciesTab = ({ dependencies, onDependencyClick }: Dependencie
                 </Badge>
               )}
             </div>
-            <p className="text-sm text-muted-foreground mb-2">by {d.manifest.author}</p>
+            <p className="text-sm text-muted-foreground mb-2">
+              by {d.manifest.author}
+              {d.constraint && <span className="ml-2 font-mono text-xs">{d.constraint}</span>}
+              {d.version && <span className="ml-2 text-xs">→ {d.version}</span>}
+            </p>
             <p className="text-sm text-foreground/80 line-clamp-2">
               {d.manifest.description || 'No description available.'}
             </p>
@@ -91,6 +91,20 @@ export const useAddonActions = ({
         })
       })
 
+      if (installResult.conflictingAddons.length > 0) {
+        const conflicting = installResult.conflictingAddons
+        toast({
+          title: 'Conflicting addons',
+          description: `${manifest.alias} can't be installed alongside ${conflicting.join(', ')}.`,
+          icon: AlertTriangleIcon,
+          button: {
+            label: 'Uninstall and install',
+            onClick: () => replaceConflictingAddons(conflicting),
+          },
+        })
+        return
+      }
+
       if (!installResult.success) {
         await refreshAddonStore()
         const failedDeps = installResult.dependencies.filter(
@@ -148,15 +162,32 @@ export const useAddonActions = ({
     }
   }
 
+  // Uninstalls the addons blocking the install, then installs again
+  const replaceConflictingAddons = async (names: string[]) => {
+    for (const name of names) {
+      const [result, err] = await safeCall(LocalAddonService.UninstallAddon(name, ''))
+      if (err || !result?.success) {
+        toast({
+          title: 'Error',
+          description: `Failed to uninstall ${name}: ${result?.error ?? err}`,
+          icon: AlertTriangleIcon,
+        })
+        await useAddonStore.getState().updateInstalledAddons()
+        return
+      }
+    }
+    await handleInstall()
+  }
+
   const handleUninstall = async () => {
     if (isProcessing) return
     setIsProcessing(true)
-    const [result, err] = await safeCall(LocalAddonService.UninstallAddon(manifest.name))
+    const [result, err] = await safeCall(LocalAddonService.UninstallAddon(manifest.name, ''))
     setIsProcessing(false)
-    if (err || !result) {
+    if (err || !result?.success) {
       toast({
         title: 'Error',
-        description: `Failed to uninstall ${manifest.alias}`,
+        description: `Failed to uninstall ${manifest.alias}${result?.error ? `: ${result.error}` : ''}`,
         icon: AlertTriangleIcon,
       })
       return
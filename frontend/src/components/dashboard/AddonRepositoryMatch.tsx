@@ -1,73 +1,81 @@
 import { useAtom } from 'jotai'
-import { AlertTriangleIcon, ArrowUpCircle, CheckIcon } from 'lucide-react'
+import { AlertTriangleIcon, ArrowUpCircle, CheckIcon, FileSearch } from 'lucide-react'
+import { useState } from 'react'
 import usePromise from 'react-promise-suspense'
 
 import { Button } from '@/components/ui/button'
 import { toast } from '@/components/ui/toast'
-import { repoGetManifest } from '@/lib/repo'
 import { safeCall } from '@/lib/utils.ts'
-import type { AddonManifest } from '@/lib/wails'
+import type { AdoptionCandidate } from '@/lib/wails'
+import { LocalAddonService } from '@/lib/wails'
 import { useAddonStore } from '@/stores/addonStore'
 
 import { isAddonDialogOpenAtom, selectedAddonAtom } from './atoms'
 
 const fetchData = async (name: string) => {
-  const [manifest, err] = await safeCall(repoGetManifest(name))
+  const [candidate, err] = await safeCall(LocalAddonService.GetAdoptionCandidate(name, false))
   if (err) {
     return null
   }
 
-  return manifest
+  return candidate
+}
+
+const confidenceText: Record<string, string> = {
+  high: 'Its files are identical to a release in our repository.',
+  medium: 'It looks like a release in our repository.',
+  low: 'Its name matches an addon in our repository, but its version is unknown, so it will be offered the latest release.',
 }
 
 export const AddonRepositoryMatch = ({ name }: { name: string }) => {
   const data = usePromise(fetchData, [name])
-  const { install, performBulkUpdateCheck } = useAddonStore()
+  const [compared, setCompared] = useState<AdoptionCandidate | null>(null)
+  const [isComparing, setIsComparing] = useState(false)
+  const { updateInstalledAddons, performBulkUpdateCheck } = useAddonStore()
   const [, setDialogOpen] = useAtom(isAddonDialogOpenAtom)
   const [, setSelectedAddon] = useAtom(selectedAddonAtom)
 
-  if (!data) {
+  const candidate = compared ?? data
+  if (!candidate) {
     return null
   }
 
-  const handleMatchAddon = async () => {
-    const [manifest, manifestErr] = await safeCall<AddonManifest>(repoGetManifest(name))
-    if (manifestErr || !manifest) {
-      console.error('Failed to fetch addon manifest:', manifestErr)
+  const handleCompareFiles = async () => {
+    setIsComparing(true)
+    const [result, err] = await safeCall(LocalAddonService.GetAdoptionCandidate(name, true))
+    setIsComparing(false)
+    if (err || !result) {
       toast({
         icon: AlertTriangleIcon,
         title: 'Error',
-        description: `Failed to fetch addon manifest for "${name}".`,
+        description: `Failed to compare "${name}" with its releases.`,
       })
       return
     }
+    setCompared(result)
+  }
 
-    const [, installErr] = await safeCall<boolean>(install(manifest, 'latest'))
-    if (installErr) {
-      const errorString = String(installErr)
-      if (errorString.includes('no release found')) {
-        toast({
-          icon: AlertTriangleIcon,
-          title: 'Error',
-          description: `No releases found for addon "${manifest.name}".`,
-        })
-      } else {
-        console.error('Failed to install addon during match:', installErr)
-        toast({
-          icon: AlertTriangleIcon,
-          title: 'Error',
-          description: `Failed to match addon "${manifest.name}": ${errorString.substring(0, 100)}`,
-        })
-      }
+  const handleMatchAddon = async () => {
+    const [, err] = await safeCall(
+      LocalAddonService.AdoptAddon(candidate.name, candidate.manifest.name, candidate.version)
+    )
+    if (err) {
+      console.error('Failed to adopt addon:', err)
+      toast({
+        icon: AlertTriangleIcon,
+        title: 'Error',
+        description: `Failed to match addon "${candidate.manifest.name}": ${String(err).substring(0, 100)}`,
+      })
       return
     }
 
+    await updateInstalledAddons()
     await performBulkUpdateCheck()
 
     toast({
       icon: CheckIcon,
       title: 'Addon Matched',
-      description: `"${manifest.alias}" is now managed by Classic Addon Manager.`,
+      description: `"${candidate.manifest.alias || candidate.manifest.name}" is now managed by Classic Addon Manager.`,
     })
 
     setDialogOpen(false)
@@ -77,13 +85,28 @@ export const AddonRepositoryMatch = ({ name }: { name: string }) => {
   return (
     <div className="space-y-3">
       <p className="text-sm text-muted-foreground">
-        Good news! This addon was found in our repository. Would you like Classic Addon Manager to
-        handle updates for you?
+        Good news! This addon was found in our repository as &quot;
+        {candidate.manifest.alias || candidate.manifest.name}&quot;
+        {candidate.version ? ` ${candidate.version}` : ''}. {confidenceText[candidate.confidence]}{' '}
+        Would you like Classic Addon Manager to handle updates for you?
       </p>
-      <Button className="w-full sm:w-auto" onClick={handleMatchAddon}>
-        <ArrowUpCircle className="w-4 h-4 mr-2" />
-        Yes, manage this addon
-      </Button>
+      <div className="flex flex-col gap-2 sm:flex-row">
+        <Button className="w-full sm:w-auto" onClick={handleMatchAddon}>
+          <ArrowUpCircle className="w-4 h-4 mr-2" />
+          Yes, manage this addon
+        </Button>
+        {candidate.confidence !== 'high' && (
+          <Button
+            variant="outline"
+            className="w-full sm:w-auto"
+            disabled={isComparing}
+            onClick={handleCompareFiles}
+          >
+            <FileSearch className="w-4 h-4 mr-2" />
+            {isComparing ? 'Comparing files...' : 'Compare files'}
+          </Button>
+        )}
+      </div>
     </div>
   )
 }
@@ -53,10 +53,18 @@ export const LocalAddon = ({ addon }: LocalAddonProps) => {
   useEffect(() => {
     if (!addon.isManaged) return
     const latestRelease = latestReleasesMap.get(addon.name)
-    if (!latestRelease) return
-    setHasUpdate(latestRelease.tag_name !== addon.version)
+    // No entry means the addon is up to date, pinned, or its latest release was skipped
+    if (!latestRelease) {
+      setHasUpdate(false)
+      setLatestRelease(null)
+      return
+    }
+    // The backend leaves out releases the addon doesn't want, and compares commits on branch channels
+    setHasUpdate(
+      addon.channel?.kind === 'branch' || latestRelease.tag_name !== addon.version
+    )
     setLatestRelease(latestRelease)
-  }, [latestReleasesMap, addon.isManaged, addon.name, addon.version])
+  }, [latestReleasesMap, addon.isManaged, addon.name, addon.version, addon.channel])
 
   return (
     <>
@@ -67,7 +75,8 @@ export const LocalAddon = ({ addon }: LocalAddonProps) => {
         <div
           className={cn(
             'group grid grid-cols-12 items-center gap-2 bg-muted/30 hover:bg-muted/50 h-16 w-full rounded-xl cursor-pointer transition-all px-4 py-2 border border-border/50 hover:border-primary/30 hover:shadow-sm hover:ring-1 hover:ring-primary/10',
-            isContextMenuOpen && 'ring-1 ring-primary/30 bg-muted/50 border-primary/30'
+            isContextMenuOpen && 'ring-1 ring-primary/30 bg-muted/50 border-primary/30',
+            addon.disabled && 'opacity-60'
           )}
           onClick={() => {
             setSelectedAddon(addon)
@@ -91,6 +100,14 @@ export const LocalAddon = ({ addon }: LocalAddonProps) => {
                     Outdated
                   </Badge>
                 )}
+                {addon.disabled && (
+                  <Badge
+                    variant="outline"
+                    className="ml-0.5 px-1.5 py-0.5 text-[9px] font-bold rounded-full shadow-sm shrink-0"
+                  >
+                    Disabled
+                  </Badge>
+                )}
               </div>
               <div className="flex items-center gap-3 text-xs text-muted-foreground mt-0.5">
                 {addon.isManaged && (
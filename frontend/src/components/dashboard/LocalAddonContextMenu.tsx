@@ -7,6 +7,12 @@ import {
   FolderOpen,
   GitBranchIcon,
   GithubIcon,
+  PinIcon,
+  PowerIcon,
+  PowerOffIcon,
+  PinOffIcon,
+  RadioTowerIcon,
+  SkipForwardIcon,
   Trash2Icon,
 } from 'lucide-react'
 import type { ReactNode } from 'react'
@@ -16,12 +22,18 @@ import {
   ContextMenu,
   ContextMenuContent,
   ContextMenuItem,
+  ContextMenuRadioGroup,
+  ContextMenuRadioItem,
+  ContextMenuSub,
+  ContextMenuSubContent,
+  ContextMenuSubTrigger,
   ContextMenuTrigger,
 } from '@/components/ui/context-menu'
 import { toast } from '@/components/ui/toast.tsx'
 import { safeCall } from '@/lib/utils.ts'
-import type { Addon } from '@/lib/wails'
+import type { Addon, Channel } from '@/lib/wails'
 import { LocalAddonService } from '@/lib/wails'
+import type { UninstallMode } from '@/stores/addonStore.ts'
 import { useAddonStore } from '@/stores/addonStore.ts'
 
 interface LocalAddonContextMenuProps {
@@ -35,8 +47,21 @@ export const LocalAddonContextMenu = ({
   children,
   onOpenChange,
 }: LocalAddonContextMenuProps) => {
-  const { uninstall, unmanage } = useAddonStore()
+  const {
+    uninstall,
+    unmanage,
+    removeOrphans,
+    setPinned,
+    setEnabled,
+    skipVersion,
+    setChannel,
+    latestReleasesMap,
+  } = useAddonStore()
   const openVersionSelect = useSetAtom(versionSelectAtom)
+  const latestRelease = latestReleasesMap.get(addon.name)
+  const isTrackingBranch = addon.channel?.kind === 'branch'
+  // Branch heads are told apart by commit, tagged releases by tag
+  const latestVersion = isTrackingBranch ? latestRelease?.tag.sha : latestRelease?.tag_name
 
   const openDirectory = async () => {
     const [, err] = await safeCall(LocalAddonService.OpenDirectory(addon.name))
@@ -50,35 +75,146 @@ export const LocalAddonContextMenu = ({
     }
   }
 
-  const handleAddonAction = async (action: 'uninstall' | 'unmanage') => {
-    const actionPast = action === 'uninstall' ? 'Uninstalled' : 'Unmanaged'
+  const handleUninstall = async (mode: UninstallMode = '') => {
+    const [result, err] = await safeCall(uninstall(addon, mode))
 
-    const [result, err] = await safeCall(
-      action === 'uninstall' ? uninstall(addon) : unmanage(addon)
-    )
+    if (err || !result) {
+      console.error('[LocalAddonContextMenu] Failed to uninstall addon:', err)
+      toast({
+        icon: AlertTriangleIcon,
+        title: 'Error',
+        description: `Failed to uninstall addon "${addon.alias}".`,
+      })
+      return
+    }
 
-    if (err) {
-      console.error(`[LocalAddonContextMenu] Failed to ${action} addon:`, err)
+    if (!result.success && result.dependents.length > 0) {
+      toast({
+        icon: AlertTriangleIcon,
+        title: 'Addon is required',
+        description: `"${addon.alias}" is required by ${result.dependents.join(', ')}.`,
+        button: {
+          label: 'Remove all',
+          onClick: () => handleUninstall('cascade'),
+        },
+      })
+      return
+    }
+
+    if (!result.success) {
       toast({
         icon: AlertTriangleIcon,
         title: 'Error',
-        description: `Failed to ${action} addon "${addon.alias}".`,
+        description: `Failed to uninstall addon "${addon.alias}".`,
       })
+      return
+    }
 
+    toast({
+      icon: CheckIcon,
+      title: 'Uninstalled',
+      description:
+        result.removed.length > 1
+          ? `Uninstalled ${result.removed.join(', ')}.`
+          : `Addon "${addon.alias}" has been uninstalled.`,
+    })
+
+    await offerOrphanRemoval()
+  }
+
+  // Dependencies installed for the removed addon may not be needed by anything else now
+  const offerOrphanRemoval = async () => {
+    const [orphans] = await safeCall(LocalAddonService.GetOrphanedAddons())
+    if (!orphans || orphans.length === 0) return
+
+    toast({
+      icon: Trash2Icon,
+      title: 'Unused dependencies',
+      description: `${orphans.join(', ')} ${orphans.length > 1 ? 'are' : 'is'} no longer needed.`,
+      button: {
+        label: 'Remove',
+        onClick: async () => {
+          const [result] = await safeCall(removeOrphans(orphans))
+          if (!result?.success) {
+            toast({
+              icon: AlertTriangleIcon,
+              title: 'Error',
+              description: 'Failed to remove unused dependencies.',
+            })
+          }
+        },
+      },
+    })
+  }
+
+  const handleUnmanage = async () => {
+    const [result, err] = await safeCall(unmanage(addon))
+
+    if (err || !result) {
+      console.error('[LocalAddonContextMenu] Failed to unmanage addon:', err)
+      toast({
+        icon: AlertTriangleIcon,
+        title: 'Error',
+        description: `Failed to unmanage addon "${addon.alias}".`,
+      })
       return
     }
 
-    if (result) {
+    toast({
+      icon: CheckIcon,
+      title: 'Unmanaged',
+      description: `Addon "${addon.alias}" has been unmanaged.`,
+    })
+  }
+
+  const togglePinned = async () => {
+    const [, err] = await safeCall(setPinned(addon, !addon.pinned))
+    if (err) {
+      toast({
+        icon: AlertTriangleIcon,
+        title: 'Error',
+        description: `Failed to ${addon.pinned ? 'unpin' : 'pin'} addon "${addon.alias}".`,
+      })
+    }
+  }
+
+  const toggleEnabled = async () => {
+    const [, err] = await safeCall(setEnabled(addon, !!addon.disabled))
+    if (err) {
       toast({
-        icon: CheckIcon,
-        title: actionPast,
-        description: `Addon "${addon.alias}" has been ${actionPast.toLowerCase()}.`,
+        icon: AlertTriangleIcon,
+        title: 'Error',
+        description: `Failed to ${addon.disabled ? 'enable' : 'disable'} addon "${addon.alias}".`,
       })
-    } else {
+    }
+  }
+
+  const skipLatestVersion = async (version: string) => {
+    const [, err] = await safeCall(skipVersion(addon, version))
+    if (err) {
       toast({
         icon: AlertTriangleIcon,
         title: 'Error',
-        description: `Failed to ${action} addon "${addon.alias}".`,
+        description: `Failed to skip version ${version} of "${addon.alias}".`,
+      })
+      return
+    }
+
+    toast({
+      icon: CheckIcon,
+      title: 'Version skipped',
+      description: `You won't be notified about "${addon.alias}" ${version}.`,
+    })
+  }
+
+  const changeChannel = async (kind: string) => {
+    const channel: Channel = kind === 'branch' ? { kind, branch: addon.branch } : { kind }
+    const [, err] = await safeCall(setChannel(addon, channel))
+    if (err) {
+      toast({
+        icon: AlertTriangleIcon,
+        title: 'Error',
+        description: `Failed to change the release channel of "${addon.alias}".`,
       })
     }
   }
@@ -91,6 +227,10 @@ export const LocalAddonContextMenu = ({
           <FolderOpen size={16} />
           Open directory
         </ContextMenuItem>
+        <ContextMenuItem onClick={toggleEnabled}>
+          {addon.disabled ? <PowerIcon size={16} /> : <PowerOffIcon size={16} />}
+          <span>{addon.disabled ? 'Enable' : 'Disable'}</span>
+        </ContextMenuItem>
         {addon.isManaged && (
           <>
             <ContextMenuItem onClick={() => Browser.OpenURL(`https://github.com/${addon.repo}`)}>
@@ -110,13 +250,46 @@ export const LocalAddonContextMenu = ({
               <span>Install other version</span>
             </ContextMenuItem>
 
-            <ContextMenuItem variant="warning" onClick={() => handleAddonAction('unmanage')}>
+            <ContextMenuItem onClick={togglePinned}>
+              {addon.pinned ? <PinOffIcon size={16} /> : <PinIcon size={16} />}
+              <span>{addon.pinned ? 'Unpin version' : 'Pin version'}</span>
+            </ContextMenuItem>
+
+            {latestRelease && latestVersion && (
+              <ContextMenuItem onClick={() => skipLatestVersion(latestVersion)}>
+                <SkipForwardIcon size={16} />
+                <span>
+                  Skip version {isTrackingBranch ? latestVersion.slice(0, 7) : latestVersion}
+                </span>
+              </ContextMenuItem>
+            )}
+
+            <ContextMenuSub>
+              <ContextMenuSubTrigger>
+                <RadioTowerIcon size={16} />
+                <span>Release channel</span>
+              </ContextMenuSubTrigger>
+              <ContextMenuSubContent>
+                <ContextMenuRadioGroup
+                  value={addon.channel?.kind || 'stable'}
+                  onValueChange={changeChannel}
+                >
+                  <ContextMenuRadioItem value="stable">Stable</ContextMenuRadioItem>
+                  <ContextMenuRadioItem value="prerelease">Prerelease</ContextMenuRadioItem>
+                  <ContextMenuRadioItem value="branch" disabled={!addon.branch}>
+                    Branch {addon.branch}
+                  </ContextMenuRadioItem>
+                </ContextMenuRadioGroup>
+              </ContextMenuSubContent>
+            </ContextMenuSub>
+
+            <ContextMenuItem variant="warning" onClick={handleUnmanage}>
               <AlertTriangleIcon size={16} />
               <span>Unmanage</span>
             </ContextMenuItem>
           </>
         )}
-        <ContextMenuItem variant="destructive" onClick={() => handleAddonAction('uninstall')}>
+        <ContextMenuItem variant="destructive" onClick={() => handleUninstall()}>
           <Trash2Icon size={16} />
           Uninstall
         </ContextMenuItem>
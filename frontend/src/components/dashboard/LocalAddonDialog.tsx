@@ -154,8 +154,16 @@ export const LocalAddonDialog = ({ addon, onOpenChange, open }: LocalAddonDialog
   }
 
   const handleUninstall = async () => {
-    const didUninstall = await uninstall(addon)
-    if (!didUninstall) {
+    const [result] = await safeCall(uninstall(addon))
+    if (result && !result.success && result.dependents.length > 0) {
+      toast({
+        title: 'Addon is required',
+        description: `${addon.alias} is required by ${result.dependents.join(', ')}`,
+        icon: AlertTriangleIcon,
+      })
+      return
+    }
+    if (!result?.success) {
       toast({
         title: 'Error',
         description: 'Failed to uninstall addon, check log file for more information',
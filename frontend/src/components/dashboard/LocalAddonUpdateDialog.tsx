@@ -20,8 +20,10 @@ import {
   DialogTitle,
 } from '@/components/ui/dialog'
 import { repoGetManifest } from '@/lib/repo.ts'
-import { formatToLocalTime, safeCall } from '@/lib/utils'
+import { describeOverwrittenPaths, formatToLocalTime, safeCall } from '@/lib/utils'
 import type { Addon, Release } from '@/lib/wails'
+import { LocalAddonService } from '@/lib/wails'
+import type { ModificationMode } from '@/stores/addonStore'
 import { useAddonStore } from '@/stores/addonStore'
 import { useUpdateDialogStore } from '@/stores/updateDialogStore'
 
@@ -36,6 +38,8 @@ interface Props {
 export const LocalAddonUpdateDialog = ({ addon, release }: Props) => {
   const [changelog, setChangelog] = useState<string>('')
   const [isUpdating, setIsUpdating] = useState<boolean>(false)
+  // Files changed since the addon was installed, which the user has to decide about before updating
+  const [modifiedFiles, setModifiedFiles] = useState<string[]>([])
   const { open, setOpen } = useUpdateDialogStore()
   const { update } = useAddonStore()
 
@@ -48,14 +52,26 @@ export const LocalAddonUpdateDialog = ({ addon, release }: Props) => {
   }, [open, release.body])
 
   const handleUpdateClick = async () => {
+    const [modifications] = await safeCall(LocalAddonService.GetLocalModifications(addon.name))
+    const changed = [...(modifications?.modified ?? []), ...(modifications?.added ?? [])]
+    if (changed.length > 0) {
+      setModifiedFiles(changed)
+      return
+    }
+
+    await runUpdate('')
+  }
+
+  const runUpdate = async (mode: ModificationMode) => {
+    setModifiedFiles([])
     setIsUpdating(true)
 
     const updateOperation = async () => {
       const manifest = await repoGetManifest(addon.name)
-      return await update(manifest, release.tag_name)
+      return await update(manifest, release.tag_name, mode)
     }
 
-    const [didInstall, err] = await safeCall<boolean>(updateOperation())
+    const [result, err] = await safeCall(updateOperation())
 
     if (err) {
       if (err.message.includes('not found')) {
@@ -74,12 +90,18 @@ export const LocalAddonUpdateDialog = ({ addon, release }: Props) => {
       return
     }
 
-    if (didInstall) {
+    if (result?.success) {
       toast({
         title: 'Addon updated',
-        description: `${addon.alias} was updated to ${release.tag_name}`,
+        description: `${addon.alias} was updated to ${release.tag_name}.${describeOverwrittenPaths(result.overwrittenPaths)}`,
         icon: ArrowUpCircle,
       })
+    } else {
+      toast({
+        title: 'Error',
+        description: `Failed to update ${addon.alias}`,
+        icon: AlertTriangleIcon,
+      })
     }
     setIsUpdating(false)
   }
@@ -139,8 +161,39 @@ export const LocalAddonUpdateDialog = ({ addon, release }: Props) => {
           )}
         </div>
 
+        {modifiedFiles.length > 0 && (
+          <div className="mx-6 mb-4 border rounded-lg p-4 bg-card text-sm space-y-2">
+            <p className="inline-flex items-center gap-1.5 font-medium">
+              <AlertTriangleIcon className="w-4 h-4" />
+              You changed files of this addon since it was installed
+            </p>
+            <ul className="list-disc pl-5 text-muted-foreground max-h-32 overflow-y-auto">
+              {modifiedFiles.map(file => (
+                <li key={file}>{file}</li>
+              ))}
+            </ul>
+            <p className="text-muted-foreground">
+              Updating replaces them. Back them up to the addon&apos;s backup folder, or keep your
+              versions on top of the new release. Kept files replace the release&apos;s copies whole,
+              so any fixes the release makes to them are lost.
+            </p>
+          </div>
+        )}
+
         <DialogFooter className="p-4 border-t">
-          {isUpdating ? (
+          {modifiedFiles.length > 0 ? (
+            <>
+              <Button type="button" variant="outline" onClick={() => setModifiedFiles([])}>
+                Cancel
+              </Button>
+              <Button type="button" variant="outline" onClick={() => runUpdate('backup')}>
+                Back up and update
+              </Button>
+              <Button type="button" variant="default" onClick={() => runUpdate('carry')}>
+                Keep my changes
+              </Button>
+            </>
+          ) : isUpdating ? (
             <Button type="button" variant="default" disabled className="w-full sm:w-auto">
               <LoaderCircle className="w-4 h-4 mr-2 animate-spin" />
               Updating...
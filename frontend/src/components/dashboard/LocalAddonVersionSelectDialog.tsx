@@ -23,17 +23,11 @@ import {
   SelectValue,
 } from '@/components/ui/select'
 import { toast } from '@/components/ui/toast.tsx'
-import { cn } from '@/lib/utils'
+import { cn, describeOverwrittenPaths } from '@/lib/utils'
 import type { Addon, Release } from '@/lib/wails'
 import { RemoteAddonService } from '@/lib/wails'
 import { useAddonStore } from '@/stores/addonStore'
 
-interface ApiResponse {
-  status: boolean
-  message: string
-  data: Release[]
-}
-
 interface Props {
   addon: Addon
 }
@@ -144,25 +138,13 @@ const fetchReleases = async (addon: Addon): Promise<Release[]> => {
 
   const promise = (async () => {
     try {
-      const response = await fetch(`https://aac.gaijin.dev/addon/${addon.name}/releases`)
-      if (!response.ok) {
-        throw new Error(`HTTP error! status: ${response.status}`)
-      }
-      const result: ApiResponse = await response.json()
-
-      if (result.status && result.data) {
-        // Filter out duplicates based on tag_name
-        const uniqueReleasesMap = new Map<string, Release>()
-        for (const release of result.data) {
-          if (!uniqueReleasesMap.has(release.tag_name)) {
-            uniqueReleasesMap.set(release.tag_name, release)
-          }
-        }
-
-        return Array.from(uniqueReleasesMap.values())
-      } else {
-        throw new Error(result.message || 'Failed to fetch releases: Invalid API response')
+      const releases: Release[] = []
+      for (let page = 1; ; page++) {
+        const result = await RemoteAddonService.GetAddonReleases(addon.name, page, 100)
+        releases.push(...result.releases)
+        if (!result.hasMore) break
       }
+      return releases
     } catch (e: unknown) {
       console.error(`Failed to fetch releases for ${addon.name}:`, e)
       const errorMessage = e instanceof Error ? e.message : 'An unknown error occurred.'
@@ -253,11 +235,11 @@ export const LocalAddonVersionSelectDialog = ({ addon }: Props) => {
       if (!manifest) {
         throw new Error('Failed to fetch addon manifest')
       }
-      const didInstall = await update(manifest, selectedVersion)
-      if (didInstall) {
+      const result = await update(manifest, selectedVersion)
+      if (result?.success) {
         toast({
           title: 'Success',
-          description: `Installed ${addon.alias} version ${selectedVersion}`,
+          description: `Installed ${addon.alias} version ${selectedVersion}.${describeOverwrittenPaths(result.overwrittenPaths)}`,
         })
         setOpen(false)
         setAddon(null)
@@ -1,7 +1,7 @@
 import { ApplicationService } from '@/lib/wails'
 import { useUserStore } from '@/stores/userStore.ts'
 
-const API_URL = 'https://aac.gaijin.dev'
+const DEFAULT_API_URL = 'https://aac.gaijin.dev'
 
 function createHeaders(version: string, token: string): Record<string, string> {
   return {
@@ -15,6 +15,7 @@ function createHeaders(version: string, token: string): Record<string, string> {
 class ApiClient {
   private static instance: ApiClient | null = null
   private version: string | null = null
+  private baseUrl: string = DEFAULT_API_URL
   private readonly initPromise: Promise<void>
 
   private constructor() {
@@ -28,6 +29,12 @@ class ApiClient {
       console.error('Failed to initialize API client with version:', error)
       this.version = 'unknown'
     }
+
+    try {
+      this.baseUrl = await ApplicationService.GetApiURL()
+    } catch (error) {
+      console.error('Failed to get API URL, falling back to default:', error)
+    }
   }
 
   static getInstance(): ApiClient {
@@ -44,7 +51,7 @@ class ApiClient {
   async get(url: string): Promise<Response> {
     await this.initPromise
 
-    return fetch(API_URL + url, {
+    return fetch(this.baseUrl + url, {
       method: 'GET',
       headers: createHeaders(this.version!, this.getToken()),
     })
@@ -53,7 +60,7 @@ class ApiClient {
   async post(url: string, data: unknown): Promise<Response> {
     await this.initPromise
 
-    return fetch(API_URL + url, {
+    return fetch(this.baseUrl + url, {
       method: 'POST',
       headers: createHeaders(this.version!, this.getToken()),
       body: JSON.stringify(data),
@@ -38,3 +38,13 @@ export function daysAgo(dateString: string): number {
   const diff = now.getTime() - date.getTime()
   return Math.floor(diff / (1000 * 60 * 60 * 24))
 }
+
+/**
+ * Describes the preserved files an update kept over the copy shipped by the new release.
+ */
+export function describeOverwrittenPaths(paths: Array<string> | null | undefined): string {
+  if (!paths || paths.length === 0) {
+    return ''
+  }
+  return ` Your copy of ${paths.join(', ')} was kept over the one in the new release.`
+}
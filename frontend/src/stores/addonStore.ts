@@ -1,7 +1,14 @@
 import { create } from 'zustand'
 
 import { safeCall } from '@/lib/utils.ts'
-import type { Addon, AddonManifest, Release } from '@/lib/wails'
+import type {
+  Addon,
+  AddonManifest,
+  Channel,
+  InstallWithDependenciesResult,
+  Release,
+  UninstallResult,
+} from '@/lib/wails'
 import { LocalAddonService, RemoteAddonService } from '@/lib/wails'
 
 interface AddonState {
@@ -18,12 +25,30 @@ interface AddonState {
   updateInstalledAddons: () => Promise<void>
 
   install: (manifest: AddonManifest, version: string) => Promise<boolean>
-  update: (manifest: AddonManifest, version: string) => Promise<boolean>
+  update: (
+    manifest: AddonManifest,
+    version: string,
+    mode?: ModificationMode
+  ) => Promise<InstallWithDependenciesResult | null>
 
-  uninstall: (addon: Addon) => Promise<boolean>
+  uninstall: (addon: Addon, mode?: UninstallMode) => Promise<UninstallResult>
   unmanage: (addon: Addon) => Promise<boolean>
+  removeOrphans: (names: Array<string>) => Promise<UninstallResult>
+
+  setPinned: (addon: Addon, pinned: boolean) => Promise<void>
+  setEnabled: (addon: Addon, enabled: boolean) => Promise<void>
+  skipVersion: (addon: Addon, version: string) => Promise<void>
+  setChannel: (addon: Addon, channel: Channel) => Promise<void>
 }
 
+// How to uninstall an addon other installed addons depend on: refuse (''), remove the dependents too
+// ('cascade') or keep it installed and only unmanage it ('unmanage')
+export type UninstallMode = '' | 'cascade' | 'unmanage'
+
+// What an update does with files changed since the addon was installed: refuse (''), save them to the
+// addon's backup folder ('backup') or copy them over the new release ('carry')
+export type ModificationMode = '' | 'backup' | 'carry'
+
 export const useAddonStore = create<AddonState>((set, get) => ({
   // Initial state
   installedAddons: [],
@@ -116,33 +141,46 @@ export const useAddonStore = create<AddonState>((set, get) => ({
     return false
   },
 
-  update: async (manifest: AddonManifest, version: string) => {
-    const [result, err] = await safeCall(RemoteAddonService.UpdateAddon(manifest, version))
-    if (err) {
+  update: async (manifest: AddonManifest, version: string, mode: ModificationMode = '') => {
+    const [result, err] = await safeCall(RemoteAddonService.UpdateAddon(manifest, version, mode))
+    if (err || !result) {
       console.error('[AddonStore] Failed to update addon:', err)
-      return false
+      return null
     }
 
-    if (result) {
-      await get().updateInstalledAddons()
-      // Recompute available updates so the sidebar badge updates immediately
-      await get().performBulkUpdateCheck()
-      return true
-    }
+    // Dependencies may have been installed even when the update itself failed
+    await get().updateInstalledAddons()
+    // Recompute available updates so the sidebar badge updates immediately
+    await get().performBulkUpdateCheck()
 
-    return false
+    if (!result.success) {
+      console.error('[AddonStore] Failed to update addon:', result.mainAddon.error)
+    }
+    return result
   },
 
-  uninstall: async (addon: Addon) => {
-    const [result, err] = await safeCall(LocalAddonService.UninstallAddon(addon.name))
-    if (err) {
+  uninstall: async (addon: Addon, mode: UninstallMode = '') => {
+    const [result, err] = await safeCall(LocalAddonService.UninstallAddon(addon.name, mode))
+    if (err || !result) {
       console.error('[AddonStore] Failed to uninstall addon:', err)
       throw err
     }
 
     await get().updateInstalledAddons()
 
-    return result ?? false
+    return result
+  },
+
+  removeOrphans: async (names: Array<string>) => {
+    const [result, err] = await safeCall(LocalAddonService.RemoveOrphanedAddons(names))
+    if (err || !result) {
+      console.error('[AddonStore] Failed to remove orphaned addons:', err)
+      throw err
+    }
+
+    await get().updateInstalledAddons()
+
+    return result
   },
 
   unmanage: async (addon: Addon) => {
@@ -156,4 +194,47 @@ export const useAddonStore = create<AddonState>((set, get) => ({
 
     return result ?? false
   },
+
+  setPinned: async (addon: Addon, pinned: boolean) => {
+    const [, err] = await safeCall(LocalAddonService.SetPinned(addon.name, pinned))
+    if (err) {
+      console.error('[AddonStore] Failed to pin addon:', err)
+      throw err
+    }
+
+    await get().updateInstalledAddons()
+    await get().performBulkUpdateCheck()
+  },
+
+  setEnabled: async (addon: Addon, enabled: boolean) => {
+    const [, err] = await safeCall(LocalAddonService.SetEnabled(addon.name, enabled))
+    if (err) {
+      console.error('[AddonStore] Failed to change enabled state of addon:', err)
+      throw err
+    }
+
+    await get().updateInstalledAddons()
+  },
+
+  skipVersion: async (addon: Addon, version: string) => {
+    const [, err] = await safeCall(LocalAddonService.SkipVersion(addon.name, version))
+    if (err) {
+      console.error('[AddonStore] Failed to skip version:', err)
+      throw err
+    }
+
+    await get().updateInstalledAddons()
+    await get().performBulkUpdateCheck()
+  },
+
+  setChannel: async (addon: Addon, channel: Channel) => {
+    const [, err] = await safeCall(LocalAddonService.SetChannel(addon.name, channel))
+    if (err) {
+      console.error('[AddonStore] Failed to change release channel:', err)
+      throw err
+    }
+
+    await get().updateInstalledAddons()
+    await get().performBulkUpdateCheck()
+  },
 }))
@@ -7,6 +7,8 @@ import (
 	"ClassicAddonManager/backend/file"
 	"ClassicAddonManager/backend/logger"
 	"ClassicAddonManager/backend/services"
+	"ClassicAddonManager/backend/shared"
+	"context"
 	"embed"
 	"encoding/json"
 	"flag"
@@ -40,7 +42,7 @@ func main() {
 
 	if *addonUpdateMode {
 		addon.GenerateUpdateAddonLua(
-			addon.CheckForUpdates(),
+			addon.CheckForUpdates(context.Background()),
 		)
 		os.Exit(0)
 	}
@@ -60,6 +62,10 @@ func main() {
 		},
 	})
 
+	addon.SetDownloadProgressHandler(func(progress shared.DownloadProgress) {
+		a.Event.Emit("addonDownloadProgress", progress)
+	})
+
 	applicationServices := []application.Service{
 		application.NewService(&services.LocalAddonService{}),
 		application.NewService(&services.ApplicationService{